@@ -0,0 +1,3 @@
+module gocourse
+
+go 1.23
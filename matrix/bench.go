@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gocourse/matrixlib"
+)
+
+type benchResult struct {
+	Kernel        string `json:"kernel"`
+	Size          int    `json:"size"`
+	Nanoseconds   int64  `json:"ns"`
+	Allocs        uint64 `json:"allocs"`
+	Bytes         uint64 `json:"bytes"`
+	MaxGoroutines int    `json:"max_goroutines"`
+	Correct       bool   `json:"correct"`
+}
+
+// benchKernel runs kernel once and measures wall time, heap allocations and
+// the highest goroutine count observed while it ran.
+func benchKernel(name string, kernel matrixlib.Kernel, matA, matB, want matrixlib.IntMatrix) benchResult {
+	var peak atomic.Int64
+	peak.Store(int64(runtime.NumGoroutine()))
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if n := int64(runtime.NumGoroutine()); n > peak.Load() {
+					peak.Store(n)
+				}
+			}
+		}
+	}()
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	got := kernel(matA, matB)
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	close(stop)
+	<-sampled
+
+	return benchResult{
+		Kernel:        name,
+		Size:          len(matA),
+		Nanoseconds:   elapsed.Nanoseconds(),
+		Allocs:        after.Mallocs - before.Mallocs,
+		Bytes:         after.TotalAlloc - before.TotalAlloc,
+		MaxGoroutines: int(peak.Load()),
+		Correct:       got.Equal(want),
+	}
+}
+
+func writeBenchCSV(w io.Writer, results []benchResult) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"kernel", "size", "ns", "allocs", "bytes", "max_goroutines", "correct"})
+	for _, r := range results {
+		writer.Write([]string{
+			r.Kernel,
+			strconv.Itoa(r.Size),
+			strconv.FormatInt(r.Nanoseconds, 10),
+			strconv.FormatUint(r.Allocs, 10),
+			strconv.FormatUint(r.Bytes, 10),
+			strconv.Itoa(r.MaxGoroutines),
+			strconv.FormatBool(r.Correct),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func parseSizes(s string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(s, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid size %q", field)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	sizesFlag := fs.String("sizes", "32,64,128", "comma-separated square matrix sizes")
+	kernelsFlag := fs.String("kernels", strings.Join(matrixlib.KernelNames(), ","), "comma-separated kernels to run")
+	reps := fs.Int("reps", 3, "runs of each kernel per size")
+	seed := fs.Int64("seed", 1, "random seed for generated matrices")
+	format := fs.String("format", "csv", "report format: csv or json")
+	out := fs.String("out", "", "report file (default stdout)")
+	fs.Parse(args)
+
+	if *format != "csv" && *format != "json" {
+		fmt.Printf("unknown format %q, expected csv or json\n", *format)
+		return
+	}
+	sizes, err := parseSizes(*sizesFlag)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	names := strings.Split(*kernelsFlag, ",")
+	for _, name := range names {
+		if _, err := matrixlib.LookupKernel(name); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	var results []benchResult
+	for _, size := range sizes {
+		matA := matrixlib.RandomIntMatrix(rng, size, size)
+		matB := matrixlib.RandomIntMatrix(rng, size, size)
+		want := matrixlib.MultiplyNaive(matA, matB)
+		for _, name := range names {
+			for i := 0; i < *reps; i++ {
+				results = append(results, benchKernel(name, matrixlib.Kernels[name], matA, matB, want))
+			}
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Println("Error creating report:", err)
+			return
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "csv":
+		err = writeBenchCSV(w, results)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(results)
+	}
+	if err != nil {
+		fmt.Println("Error writing report:", err)
+		return
+	}
+
+	for _, r := range results {
+		if !r.Correct {
+			fmt.Fprintf(os.Stderr, "kernel %s produced a wrong result for size %d\n", r.Kernel, r.Size)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gocourse/matrixlib"
+)
+
+// readJobFile returns the matrix file names listed in a job file, one per
+// line. Blank lines and lines starting with # are ignored.
+func readJobFile(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, scanner.Err()
+}
+
+func runChain(args []string) {
+	fs := flag.NewFlagSet("chain", flag.ExitOnError)
+	wf := addWorkerFlags(fs)
+	readOpts := readFlags(fs)
+	job := fs.String("job", "", "file listing the matrix files of the chain, one per line")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Println("Usage: go run *.go chain [-job chain.txt] <result.txt> [matrix.txt...]")
+		return
+	}
+	resultFile := fs.Arg(0)
+	names := fs.Args()[1:]
+	if *job != "" {
+		jobNames, err := readJobFile(*job)
+		if err != nil {
+			fmt.Println("Error reading job file:", err)
+			return
+		}
+		names = append(jobNames, names...)
+	}
+	if len(names) < 2 {
+		fmt.Println("A chain needs at least two matrices")
+		return
+	}
+
+	opts, err := readOpts()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	mats := make([]matrixlib.IntMatrix, len(names))
+	for i, name := range names {
+		mats[i], err = matrixlib.ReadIntMatrixFile(name, opts)
+		if err != nil {
+			fmt.Println("Error reading matrix:", err)
+			return
+		}
+	}
+
+	dims, err := matrixlib.ChainDims(mats, names)
+	if err != nil {
+		fmt.Println("Matrices cannot be multiplied:", err)
+		return
+	}
+	cost, split := matrixlib.ChainOrder(dims)
+	optimal := cost[0][len(mats)-1]
+	naive := matrixlib.LeftToRightCost(dims)
+
+	multiply, stop, err := wf.multiplier()
+	if err != nil {
+		fmt.Println("Error starting workers:", err)
+		return
+	}
+	defer stop()
+
+	result, err := matrixlib.MultiplyChain(mats, split, 0, len(mats)-1, multiply)
+	if err != nil {
+		fmt.Println("Error multiplying chain:", err)
+		return
+	}
+
+	fmt.Println("Order:", matrixlib.Parenthesize(split, names, 0, len(mats)-1))
+	fmt.Printf("Scalar multiplications: %d (left to right: %d)\n", optimal, naive)
+	fmt.Printf("FLOPs: %d (left to right: %d)\n", 2*optimal, 2*naive)
+	if naive > 0 {
+		fmt.Printf("Saved: %d FLOPs (%.1f%%)\n", 2*(naive-optimal), 100*float64(naive-optimal)/float64(naive))
+	}
+
+	if err := matrixlib.WriteIntMatrixFile(resultFile, result); err != nil {
+		fmt.Println("Error writing result:", err)
+	}
+}
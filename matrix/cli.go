@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gocourse/matrixlib"
+)
+
+// readFlags registers the flags that control how matrix files are parsed.
+func readFlags(fs *flag.FlagSet) func() (matrixlib.ReadOptions, error) {
+	delimName := fs.String("delim", "space", "value delimiter: space, comma, semicolon or tab")
+	comment := fs.String("comment", "#", "prefix of comment lines in matrix files")
+	return func() (matrixlib.ReadOptions, error) {
+		delim, err := matrixlib.ParseDelimiter(*delimName)
+		if err != nil {
+			return matrixlib.ReadOptions{}, err
+		}
+		return matrixlib.ReadOptions{Delimiter: delim, Comment: *comment}, nil
+	}
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "worker":
+			runWorker(os.Args[2:])
+			return
+		case "chain":
+			runChain(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		}
+	}
+	runMultiply(os.Args[1:])
+}
+
+func runWorker(args []string) {
+	if len(args) < 2 {
+		fmt.Println("Usage: go run *.go worker <tcp|unix> <address>")
+		return
+	}
+	if err := serveWorker(args[0], args[1]); err != nil {
+		fmt.Println("Error running worker:", err)
+	}
+}
+
+func runMultiply(args []string) {
+	fs := flag.NewFlagSet("multiply", flag.ExitOnError)
+	wf := addWorkerFlags(fs)
+	readOpts := readFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() < 3 {
+		fmt.Println("Usage: go run *.go [-workers addrs | -spawn n] <matrixA.txt> <matrixB.txt> <result.txt>")
+		return
+	}
+	opts, err := readOpts()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	matA, err := matrixlib.ReadIntMatrixFile(fs.Arg(0), opts)
+	if err != nil {
+		fmt.Println("Error reading matrix A:", err)
+		return
+	}
+
+	matB, err := matrixlib.ReadIntMatrixFile(fs.Arg(1), opts)
+	if err != nil {
+		fmt.Println("Error reading matrix B:", err)
+		return
+	}
+
+	if len(matA[0]) != len(matB) {
+		fmt.Printf("Matrices cannot be multiplied: %dx%d and %dx%d\n", len(matA), len(matA[0]), len(matB), len(matB[0]))
+		return
+	}
+
+	multiply, stop, err := wf.multiplier()
+	if err != nil {
+		fmt.Println("Error starting workers:", err)
+		return
+	}
+	defer stop()
+
+	result, err := multiply(matA, matB)
+	if err != nil {
+		fmt.Println("Error multiplying on workers:", err)
+		return
+	}
+
+	fmt.Println("Resulting Matrix:")
+	for _, row := range result {
+		fmt.Println(row)
+	}
+
+	if err := matrixlib.WriteIntMatrixFile(fs.Arg(2), result); err != nil {
+		fmt.Println("Error writing result:", err)
+	}
+}
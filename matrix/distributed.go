@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gocourse/matrixlib"
+)
+
+// serveWorker listens on the given network address and serves
+// matrixlib.MatrixWorker. The actual listening address is printed on the
+// first line of stdout so a parent process can connect to workers started
+// on port 0.
+func serveWorker(network, address string) error {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	fmt.Println(matrixlib.WorkerAddr(listener))
+	return matrixlib.ServeWorker(listener)
+}
+
+// spawnLocalWorkers starts n worker processes of the current executable on
+// localhost and returns their addresses and a function that stops them.
+func spawnLocalWorkers(n int) ([]string, func(), error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cmds []*exec.Cmd
+	stop := func() {
+		for _, cmd := range cmds {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	}
+
+	var addrs []string
+	for i := 0; i < n; i++ {
+		cmd := exec.Command(exe, "worker", "tcp", "127.0.0.1:0")
+		cmd.Stderr = os.Stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			stop()
+			return nil, nil, err
+		}
+		cmds = append(cmds, cmd)
+
+		line, err := bufio.NewReader(stdout).ReadString('\n')
+		if err != nil {
+			stop()
+			return nil, nil, fmt.Errorf("worker %d did not report its address: %w", i, err)
+		}
+		addrs = append(addrs, strings.TrimSpace(line))
+	}
+	return addrs, stop, nil
+}
+
+type workerFlags struct {
+	kernel    *string
+	workers   *string
+	spawn     *int
+	blockSize *int
+	attempts  *int
+	timeout   *time.Duration
+}
+
+// addWorkerFlags registers the flags that select where multiplication runs.
+func addWorkerFlags(fs *flag.FlagSet) *workerFlags {
+	return &workerFlags{
+		kernel:    fs.String("kernel", "cell", "local multiplication kernel: "+strings.Join(matrixlib.KernelNames(), ", ")),
+		workers:   fs.String("workers", "", "comma-separated worker addresses (tcp:host:port or unix:/path)"),
+		spawn:     fs.Int("spawn", 0, "number of local worker processes to start"),
+		blockSize: fs.Int("block", 16, "rows per block sent to a worker"),
+		attempts:  fs.Int("attempts", 3, "calls to a worker before it is dropped"),
+		timeout:   fs.Duration("timeout", matrixlib.DefaultCallTimeout, "time allowed for one call to a worker"),
+	}
+}
+
+// multiplier returns the selected local kernel when no workers are
+// configured, otherwise a distributed one. The returned stop function must
+// be called to shut down spawned workers.
+func (f *workerFlags) multiplier() (matrixlib.MultiplyFunc, func(), error) {
+	kernel, err := matrixlib.LookupKernel(*f.kernel)
+	if err != nil {
+		return nil, nil, err
+	}
+	var addrs []string
+	if *f.workers != "" {
+		addrs = strings.Split(*f.workers, ",")
+	}
+	stop := func() {}
+	if *f.spawn > 0 {
+		spawned, stopSpawned, err := spawnLocalWorkers(*f.spawn)
+		if err != nil {
+			return nil, nil, err
+		}
+		stop = stopSpawned
+		addrs = append(addrs, spawned...)
+	}
+
+	if len(addrs) == 0 {
+		return func(matA, matB matrixlib.IntMatrix) (matrixlib.IntMatrix, error) {
+			return kernel(matA, matB), nil
+		}, stop, nil
+	}
+	return func(matA, matB matrixlib.IntMatrix) (matrixlib.IntMatrix, error) {
+		return matrixlib.MultiplyDistributed(matA, matB, addrs, *f.blockSize, *f.attempts, *f.timeout)
+	}, stop, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gocourse/matrixlib"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "complex":
+			if len(os.Args) < 3 {
+				fmt.Println("Використання: go run *.go complex <файл>")
+				return
+			}
+			runComplex(os.Args[2])
+			return
+		case "generate":
+			runGenerate(os.Args[2:])
+			return
+		}
+	}
+	runInteractive()
+}
+
+// runInteractive — початковий діалоговий режим програми.
+func runInteractive() {
+	fmt.Println("Програма для роботи з матрицями")
+	var rows, cols int
+	fmt.Print("Введіть кількість рядків: ")
+	fmt.Scan(&rows)
+	fmt.Print("Введіть кількість стовпців: ")
+	fmt.Scan(&cols)
+
+	m := matrixlib.NewMatrix(rows, cols)
+	m.Input()
+	fmt.Println("Введена матриця:")
+	m.Print()
+
+	////////////////////////////////////////////
+	fmt.Print("Введіть кількість рядків: ")
+	fmt.Scan(&rows)
+	fmt.Print("Введіть кількість стовпців: ")
+	fmt.Scan(&cols)
+
+	m2 := matrixlib.NewMatrix(rows, cols)
+	m2.Input()
+	fmt.Println("Введена матриця:")
+	m2.Print()
+	////////////////////////////////////////////
+
+	fmt.Println("\nТранспонована матриця:")
+	transposed := m.Transpose()
+	transposed.Print()
+
+	fmt.Println("\nДетермінант першої матриці:")
+	det, err := m.Determinant()
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Printf("%.2f\n", det)
+	}
+
+	fmt.Println("\nОбернута перша матриця:")
+	inverse, err := m.Inverse()
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		inverse.Print()
+	}
+
+	fmt.Println("\nДодавання:")
+	result, err := m.Add(m2)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		result.Print()
+	}
+
+	fmt.Println("\nВіднімання:")
+	result, err = m.Subtract(m2)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		result.Print()
+	}
+
+	fmt.Println("\nМноження:")
+	result, err = m.Multiply(m2)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		result.Print()
+	}
+
+	fmt.Println("\nСортування по рядках:")
+	r := &matrixlib.RowLexicographicSort{Matrix: m}
+	r.Sort()
+	fmt.Println("Матриця після сортування:")
+	m.Print()
+
+	fmt.Println("\nСортування по стовпцях:")
+	c := &matrixlib.ColumnLexicographicSort{Matrix: m}
+	c.Sort()
+	fmt.Println("Матриця після сортування:")
+	m.Print()
+
+	fmt.Println("Розвʼязок СЛАР")
+	rows, cols = m.Dims()
+	b := make([]float64, rows)
+	fmt.Print("Введіть вектор вільних членів: ")
+	for i := 0; i < rows; i++ {
+		fmt.Scan(&b[i])
+	}
+	solution, err := m.SolveSystem(b)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println("Розвʼязок СЛАР:")
+		for i, x := range solution {
+			fmt.Printf("x%d = %.2f\n", i+1, x)
+		}
+
+		checkSolution := make([]float64, rows)
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				checkSolution[i] += m.At(i, j) * solution[j]
+			}
+		}
+		fmt.Println("Перевірка розв'язку:")
+		for i, x := range checkSolution {
+			fmt.Printf("x%d = %.2f\n", i+1, x)
+		}
+	}
+
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gocourse/matrixlib"
+)
+
+func runComplex(filename string) {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Println("Помилка відкриття файлу:", err)
+		return
+	}
+	defer file.Close()
+
+	m, err := matrixlib.ReadComplexMatrix(file)
+	if err != nil {
+		fmt.Println("Помилка читання матриці:", err)
+		return
+	}
+	fmt.Println("Введена матриця:")
+	m.Print()
+
+	fmt.Println("\nЕрмітово спряжена матриця:")
+	m.ConjugateTranspose().Print()
+
+	if m.IsHermitian(1e-10) {
+		fmt.Println("\nМатриця є ермітовою")
+	} else {
+		fmt.Println("\nМатриця не є ермітовою")
+	}
+
+	fmt.Println("\nДетермінант:")
+	det, err := m.Determinant()
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println(matrixlib.FormatComplex(det, 2))
+	}
+
+	fmt.Println("\nОбернена матриця:")
+	inverse, err := m.Inverse()
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		inverse.Print()
+	}
+}
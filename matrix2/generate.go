@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"gocourse/matrixlib"
+)
+
+func parseFloats(s string) ([]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var values []float64
+	for _, field := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("некоректне число %q", field)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// generateMatrix будує матрицю заданого виду з параметрів командного рядка.
+func generateMatrix(kind string, rows, cols int, low, high float64, values, values2, values3 []float64, rng *rand.Rand) (*matrixlib.Matrix, error) {
+	if (kind == "diagonal" || kind == "vandermonde") && len(values) == 0 {
+		return nil, fmt.Errorf("для %s потрібен прапорець -values", kind)
+	}
+	switch kind {
+	case "identity":
+		return matrixlib.Identity(rows), nil
+	case "zeros":
+		return matrixlib.Zeros(rows, cols), nil
+	case "ones":
+		return matrixlib.Ones(rows, cols), nil
+	case "diagonal":
+		return matrixlib.Diagonal(values), nil
+	case "uniform":
+		return matrixlib.RandomUniform(rows, cols, low, high, rng), nil
+	case "normal":
+		return matrixlib.RandomNormal(rows, cols, low, high, rng), nil
+	case "spd":
+		return matrixlib.RandomSPD(rows, rng), nil
+	case "orthogonal":
+		return matrixlib.RandomOrthogonal(rows, rng), nil
+	case "hilbert":
+		return matrixlib.Hilbert(rows), nil
+	case "vandermonde":
+		return matrixlib.Vandermonde(values, cols), nil
+	case "toeplitz":
+		return matrixlib.Toeplitz(values, values2)
+	case "tridiagonal":
+		return matrixlib.Tridiagonal(values, values2, values3)
+	}
+	return nil, fmt.Errorf("невідомий вид матриці %q", kind)
+}
+
+func runGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	rows := fs.Int("rows", 3, "кількість рядків (розмір для квадратних матриць)")
+	cols := fs.Int("cols", 0, "кількість стовпців (за замовчуванням дорівнює rows)")
+	seed := fs.Int64("seed", 1, "зерно генератора випадкових чисел")
+	low := fs.Float64("low", 0, "нижня межа (uniform) або середнє (normal)")
+	high := fs.Float64("high", 1, "верхня межа (uniform) або відхилення (normal)")
+	v1 := fs.String("values", "", "значення через кому: діагональ, x для vandermonde, стовпець toeplitz, піддіагональ tridiagonal")
+	v2 := fs.String("values2", "", "перший рядок toeplitz або діагональ tridiagonal")
+	v3 := fs.String("values3", "", "наддіагональ tridiagonal")
+	out := fs.String("out", "", "файл для запису (за замовчуванням стандартний вивід)")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Println("Використання: go run *.go generate [прапорці] <identity|zeros|ones|diagonal|uniform|normal|spd|orthogonal|hilbert|vandermonde|toeplitz|tridiagonal>")
+		return
+	}
+	if *rows < 1 || *cols < 0 {
+		fmt.Println("Кількість рядків має бути додатною, а стовпців — невід'ємною")
+		fs.Usage()
+		return
+	}
+	if *cols == 0 {
+		*cols = *rows
+	}
+
+	var lists [3][]float64
+	for i, s := range []string{*v1, *v2, *v3} {
+		values, err := parseFloats(s)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		lists[i] = values
+	}
+
+	m, err := generateMatrix(fs.Arg(0), *rows, *cols, *low, *high, lists[0], lists[1], lists[2], rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Println("Помилка створення файлу:", err)
+			return
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := m.Write(w); err != nil {
+		fmt.Println("Помилка запису матриці:", err)
+	}
+}
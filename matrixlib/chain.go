@@ -0,0 +1,83 @@
+package matrixlib
+
+import "fmt"
+
+// MultiplyFunc multiplies two matrices and may fail, e.g. when the work is
+// sent to remote workers.
+type MultiplyFunc func(matA, matB IntMatrix) (IntMatrix, error)
+
+// ChainOrder computes the minimal number of scalar multiplications for a
+// chain of matrices where matrix i has dims[i] rows and dims[i+1] columns.
+// split[i][j] holds the index k at which the product i..j is split.
+func ChainOrder(dims []int) (cost [][]int, split [][]int) {
+	n := len(dims) - 1
+	cost = make([][]int, n)
+	split = make([][]int, n)
+	for i := range cost {
+		cost[i] = make([]int, n)
+		split[i] = make([]int, n)
+	}
+
+	for length := 2; length <= n; length++ {
+		for i := 0; i+length-1 < n; i++ {
+			j := i + length - 1
+			cost[i][j] = -1
+			for k := i; k < j; k++ {
+				c := cost[i][k] + cost[k+1][j] + dims[i]*dims[k+1]*dims[j+1]
+				if cost[i][j] < 0 || c < cost[i][j] {
+					cost[i][j] = c
+					split[i][j] = k
+				}
+			}
+		}
+	}
+	return cost, split
+}
+
+// LeftToRightCost is the number of scalar multiplications for ((A·B)·C)·...
+func LeftToRightCost(dims []int) int {
+	total := 0
+	for k := 1; k+1 < len(dims); k++ {
+		total += dims[0] * dims[k] * dims[k+1]
+	}
+	return total
+}
+
+// Parenthesize writes the order chosen by ChainOrder for matrices i..j.
+func Parenthesize(split [][]int, names []string, i, j int) string {
+	if i == j {
+		return names[i]
+	}
+	k := split[i][j]
+	return "(" + Parenthesize(split, names, i, k) + " " + Parenthesize(split, names, k+1, j) + ")"
+}
+
+// MultiplyChain multiplies matrices i..j in the order given by split.
+func MultiplyChain(mats []IntMatrix, split [][]int, i, j int, multiply MultiplyFunc) (IntMatrix, error) {
+	if i == j {
+		return mats[i], nil
+	}
+	k := split[i][j]
+	left, err := MultiplyChain(mats, split, i, k, multiply)
+	if err != nil {
+		return nil, err
+	}
+	right, err := MultiplyChain(mats, split, k+1, j, multiply)
+	if err != nil {
+		return nil, err
+	}
+	return multiply(left, right)
+}
+
+// ChainDims checks that consecutive matrices are compatible and returns the
+// dimension vector used by ChainOrder.
+func ChainDims(mats []IntMatrix, names []string) ([]int, error) {
+	dims := []int{len(mats[0])}
+	for i, mat := range mats {
+		if len(mat) != dims[len(dims)-1] {
+			return nil, fmt.Errorf("%s has %d rows, expected %d", names[i], len(mat), dims[len(dims)-1])
+		}
+		dims = append(dims, len(mat[0]))
+	}
+	return dims, nil
+}
@@ -0,0 +1,333 @@
+package matrixlib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// ComplexView — спільний інтерфейс для комплексних матриць.
+type ComplexView = View[complex128]
+
+type ComplexMatrix struct {
+	rows, cols int
+	data       [][]complex128
+}
+
+func NewComplexMatrix(rows, cols int) *ComplexMatrix {
+	data := make([][]complex128, rows)
+	for i := range data {
+		data[i] = make([]complex128, cols)
+	}
+	return &ComplexMatrix{rows, cols, data}
+}
+
+func (m *ComplexMatrix) Dims() (int, int) {
+	return m.rows, m.cols
+}
+
+func (m *ComplexMatrix) At(i, j int) complex128 {
+	return m.data[i][j]
+}
+
+func (m *ComplexMatrix) Set(i, j int, v complex128) {
+	m.data[i][j] = v
+}
+
+func (m *ComplexMatrix) Add(other ComplexView) (*ComplexMatrix, error) {
+	if rows, cols := other.Dims(); m.rows != rows || m.cols != cols {
+		return nil, errors.New("розміри матриць не співпадають")
+	}
+	result := NewComplexMatrix(m.rows, m.cols)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			result.data[i][j] = m.data[i][j] + other.At(i, j)
+		}
+	}
+	return result, nil
+}
+
+func (m *ComplexMatrix) Subtract(other ComplexView) (*ComplexMatrix, error) {
+	if rows, cols := other.Dims(); m.rows != rows || m.cols != cols {
+		return nil, errors.New("розміри матриць не співпадають")
+	}
+	result := NewComplexMatrix(m.rows, m.cols)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			result.data[i][j] = m.data[i][j] - other.At(i, j)
+		}
+	}
+	return result, nil
+}
+
+func (m *ComplexMatrix) Multiply(other ComplexView) (*ComplexMatrix, error) {
+	rows, cols := other.Dims()
+	if m.cols != rows {
+		return nil, errors.New("кількість стовпців першої матриці має дорівнювати кількості рядків другої")
+	}
+	result := NewComplexMatrix(m.rows, cols)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < cols; j++ {
+			for k := 0; k < m.cols; k++ {
+				result.data[i][j] += m.data[i][k] * other.At(k, j)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (m *ComplexMatrix) Transpose() *ComplexMatrix {
+	result := NewComplexMatrix(m.cols, m.rows)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			result.data[j][i] = m.data[i][j]
+		}
+	}
+	return result
+}
+
+// Ермітово спряжена матриця
+func (m *ComplexMatrix) ConjugateTranspose() *ComplexMatrix {
+	result := NewComplexMatrix(m.cols, m.rows)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			result.data[j][i] = cmplx.Conj(m.data[i][j])
+		}
+	}
+	return result
+}
+
+func (m *ComplexMatrix) IsHermitian(tolerance float64) bool {
+	if m.rows != m.cols {
+		return false
+	}
+	for i := 0; i < m.rows; i++ {
+		for j := i; j < m.cols; j++ {
+			if cmplx.Abs(m.data[i][j]-cmplx.Conj(m.data[j][i])) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (m *ComplexMatrix) copyData() [][]complex128 {
+	data := make([][]complex128, m.rows)
+	for i := range data {
+		data[i] = append([]complex128(nil), m.data[i]...)
+	}
+	return data
+}
+
+// Визначник методом Гауса з вибором головного елемента
+func (m *ComplexMatrix) Determinant() (complex128, error) {
+	if m.rows != m.cols {
+		return 0, errors.New("матриця не є квадратною")
+	}
+	data := m.copyData()
+	det := complex(1, 0)
+	for i := 0; i < m.rows; i++ {
+		maxRow := i
+		for k := i + 1; k < m.rows; k++ {
+			if cmplx.Abs(data[k][i]) > cmplx.Abs(data[maxRow][i]) {
+				maxRow = k
+			}
+		}
+		if cmplx.Abs(data[maxRow][i]) < 1e-10 {
+			return 0, nil
+		}
+		if maxRow != i {
+			data[i], data[maxRow] = data[maxRow], data[i]
+			det = -det
+		}
+		det *= data[i][i]
+		for k := i + 1; k < m.rows; k++ {
+			factor := data[k][i] / data[i][i]
+			for j := i; j < m.cols; j++ {
+				data[k][j] -= factor * data[i][j]
+			}
+		}
+	}
+	return det, nil
+}
+
+// Обернена матриця методом Гауса-Жордана
+func (m *ComplexMatrix) Inverse() (*ComplexMatrix, error) {
+	if m.rows != m.cols {
+		return nil, errors.New("матриця не є квадратною")
+	}
+	n := m.rows
+	data := m.copyData()
+	result := NewComplexMatrix(n, n)
+	for i := 0; i < n; i++ {
+		result.data[i][i] = 1
+	}
+
+	for i := 0; i < n; i++ {
+		maxRow := i
+		for k := i + 1; k < n; k++ {
+			if cmplx.Abs(data[k][i]) > cmplx.Abs(data[maxRow][i]) {
+				maxRow = k
+			}
+		}
+		if cmplx.Abs(data[maxRow][i]) < 1e-10 {
+			return nil, errors.New("матриця не є оборотньою (визначник = 0)")
+		}
+		data[i], data[maxRow] = data[maxRow], data[i]
+		result.data[i], result.data[maxRow] = result.data[maxRow], result.data[i]
+
+		pivot := data[i][i]
+		for j := 0; j < n; j++ {
+			data[i][j] /= pivot
+			result.data[i][j] /= pivot
+		}
+		for k := 0; k < n; k++ {
+			if k == i {
+				continue
+			}
+			factor := data[k][i]
+			for j := 0; j < n; j++ {
+				data[k][j] -= factor * data[i][j]
+				result.data[k][j] -= factor * result.data[i][j]
+			}
+		}
+	}
+	return result, nil
+}
+
+// Метод Гауса
+func (m *ComplexMatrix) SolveSystem(b []complex128) ([]complex128, error) {
+	if m.rows != m.cols {
+		return nil, errors.New("матриця не є квадратною")
+	}
+	if len(b) != m.rows {
+		return nil, errors.New("довжина вектора вільних членів не відповідає розміру матриці")
+	}
+
+	augmentedMatrix := make([][]complex128, m.rows)
+	for i := 0; i < m.rows; i++ {
+		augmentedMatrix[i] = append(append([]complex128(nil), m.data[i]...), b[i])
+	}
+
+	for i := 0; i < m.rows; i++ {
+		maxRow := i
+		for k := i + 1; k < m.rows; k++ {
+			if cmplx.Abs(augmentedMatrix[k][i]) > cmplx.Abs(augmentedMatrix[maxRow][i]) {
+				maxRow = k
+			}
+		}
+		augmentedMatrix[i], augmentedMatrix[maxRow] = augmentedMatrix[maxRow], augmentedMatrix[i]
+
+		if cmplx.Abs(augmentedMatrix[i][i]) < 1e-10 {
+			return nil, errors.New("система не має єдиного розв'язку")
+		}
+
+		for k := i + 1; k < m.rows; k++ {
+			factor := augmentedMatrix[k][i] / augmentedMatrix[i][i]
+			for j := i; j <= m.cols; j++ {
+				augmentedMatrix[k][j] -= factor * augmentedMatrix[i][j]
+			}
+		}
+	}
+
+	solution := make([]complex128, m.rows)
+	for i := m.rows - 1; i >= 0; i-- {
+		sum := augmentedMatrix[i][m.cols]
+		for j := i + 1; j < m.cols; j++ {
+			sum -= augmentedMatrix[i][j] * solution[j]
+		}
+		solution[i] = sum / augmentedMatrix[i][i]
+	}
+	return solution, nil
+}
+
+func (m *ComplexMatrix) Print() {
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			fmt.Printf("%18s ", FormatComplex(m.data[i][j], 2))
+		}
+		fmt.Println()
+	}
+}
+
+// parseComplex розбирає числа у записі a+bi: "3", "-2i", "1.5-0.5i", "i", "2-i".
+func parseComplex(text string) (complex128, error) {
+	s := strings.TrimSpace(text)
+	if strings.HasSuffix(s, "i") {
+		body := s[:len(s)-1]
+		if body == "" || strings.HasSuffix(body, "+") || strings.HasSuffix(body, "-") {
+			s = body + "1i"
+		}
+	}
+	c, err := strconv.ParseComplex(s, 128)
+	if err != nil {
+		return 0, fmt.Errorf("некоректне комплексне число %q", text)
+	}
+	return c, nil
+}
+
+// FormatComplex друкує число у записі a+bi; prec = -1 дає найкоротший точний запис.
+// Додавання нуля прибирає від'ємний нуль.
+func FormatComplex(c complex128, prec int) string {
+	format := byte('f')
+	if prec < 0 {
+		format = 'g'
+	}
+	re, im := real(c)+0, imag(c)+0
+	sign := "+"
+	if im < 0 {
+		sign = ""
+	}
+	return strconv.FormatFloat(re, format, prec, 64) + sign + strconv.FormatFloat(im, format, prec, 64) + "i"
+}
+
+// ReadComplexMatrix читає матрицю з елементами у записі a+bi, розділеними пробілами.
+func ReadComplexMatrix(r io.Reader) (*ComplexMatrix, error) {
+	scanner := bufio.NewScanner(r)
+	var data [][]complex128
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		row := make([]complex128, len(fields))
+		for j, field := range fields {
+			c, err := parseComplex(field)
+			if err != nil {
+				return nil, fmt.Errorf("рядок %d: %w", line, err)
+			}
+			row[j] = c
+		}
+		if len(data) > 0 && len(row) != len(data[0]) {
+			return nil, fmt.Errorf("рядок %d: очікувалось %d елементів, отримано %d", line, len(data[0]), len(row))
+		}
+		data = append(data, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("матриця порожня")
+	}
+	return &ComplexMatrix{len(data), len(data[0]), data}, nil
+}
+
+func (m *ComplexMatrix) Write(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			if j > 0 {
+				writer.WriteString(" ")
+			}
+			writer.WriteString(FormatComplex(m.data[i][j], -1))
+		}
+		writer.WriteString("\n")
+	}
+	return writer.Flush()
+}
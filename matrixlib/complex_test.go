@@ -0,0 +1,173 @@
+package matrixlib
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomComplexDominant — випадкова комплексна матриця n×n зі строгою
+// діагональною перевагою, тобто гарантовано оборотня.
+func randomComplexDominant(n int, rng *rand.Rand) *ComplexMatrix {
+	re := RandomUniform(n, n, -1, 1, rng)
+	im := RandomUniform(n, n, -1, 1, rng)
+	m := NewComplexMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			m.Set(i, j, complex(re.At(i, j), im.At(i, j)))
+		}
+		m.Set(i, i, m.At(i, i)+complex(float64(2*n), float64(n)))
+	}
+	return m
+}
+
+// complexAlmostEqual порівнює дійсні та уявні частини з точністю tol.
+func complexAlmostEqual(a, b complex128, tol float64) bool {
+	return AlmostEqual(real(a), real(b), tol) && AlmostEqual(imag(a), imag(b), tol)
+}
+
+func complexVectorsAlmostEqual(a, b []complex128, tol float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !complexAlmostEqual(a[i], b[i], tol) {
+			return false
+		}
+	}
+	return true
+}
+
+func complexIdentity(n int) *ComplexMatrix {
+	m := NewComplexMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m.Set(i, i, 1)
+	}
+	return m
+}
+
+func complexMatrixAlmostEqual(a, b *ComplexMatrix, tol float64) bool {
+	rows, cols := a.Dims()
+	if r, c := b.Dims(); r != rows || c != cols {
+		return false
+	}
+	for i := 0; i < rows; i++ {
+		if !complexVectorsAlmostEqual(a.data[i], b.data[i], tol) {
+			return false
+		}
+	}
+	return true
+}
+
+func complexFromRows(rows [][]complex128) *ComplexMatrix {
+	m := NewComplexMatrix(len(rows), len(rows[0]))
+	for i, row := range rows {
+		copy(m.data[i], row)
+	}
+	return m
+}
+
+func TestComplexInverse(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 50; trial++ {
+		n := 1 + rng.Intn(6)
+		a := randomComplexDominant(n, rng)
+		inv, err := a.Inverse()
+		if err != nil {
+			t.Fatalf("випробування %d: %v", trial, err)
+		}
+		product, err := a.Multiply(inv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !complexMatrixAlmostEqual(product, complexIdentity(n), propertyTolerance) {
+			t.Fatalf("випробування %d, розмір %d: A·A⁻¹ != I", trial, n)
+		}
+	}
+}
+
+func TestComplexSolveSystem(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 50; trial++ {
+		n := 1 + rng.Intn(6)
+		a := randomComplexDominant(n, rng)
+		re, im := randomVector(n, -10, 10, rng), randomVector(n, -10, 10, rng)
+		b := make([]complex128, n)
+		for i := range b {
+			b[i] = complex(re[i], im[i])
+		}
+		x, err := a.SolveSystem(b)
+		if err != nil {
+			t.Fatalf("випробування %d: %v", trial, err)
+		}
+		ax := make([]complex128, n)
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				ax[i] += a.At(i, j) * x[j]
+			}
+		}
+		if !complexVectorsAlmostEqual(ax, b, propertyTolerance) {
+			t.Fatalf("випробування %d, розмір %d: A·x != b", trial, n)
+		}
+	}
+}
+
+func TestComplexDeterminant(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]complex128
+		want complex128
+	}{
+		{"1×1", [][]complex128{{3 - 4i}}, 3 - 4i},
+		{"діагональна", [][]complex128{{1i, 0}, {0, 2}}, 2i},
+		{"2×2", [][]complex128{{1 + 1i, 2}, {3, 4 - 1i}}, (1+1i)*(4-1i) - 6},
+		{"перестановка рядків", [][]complex128{{0, 1}, {1i, 0}}, -1i},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			det, err := complexFromRows(tt.rows).Determinant()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !complexAlmostEqual(det, tt.want, DefaultTolerance) {
+				t.Errorf("det = %v, очікувалось %v", det, tt.want)
+			}
+		})
+	}
+}
+
+func TestComplexSingular(t *testing.T) {
+	singular := map[string]*ComplexMatrix{
+		// Другий рядок — перший, помножений на i.
+		"залежні рядки":  complexFromRows([][]complex128{{1 + 1i, 2}, {-1 + 1i, 2i}}),
+		"нульовий рядок": complexFromRows([][]complex128{{1, 2i, 3}, {0, 0, 0}, {4, 5, 6i}}),
+	}
+	for name, a := range singular {
+		t.Run(name, func(t *testing.T) {
+			det, err := a.Determinant()
+			if err != nil || det != 0 {
+				t.Errorf("Determinant() = %v, %v; очікувалось 0", det, err)
+			}
+			if _, err := a.Inverse(); err == nil {
+				t.Error("Inverse() не повернув помилку для виродженої матриці")
+			}
+			rows, _ := a.Dims()
+			if _, err := a.SolveSystem(make([]complex128, rows)); err == nil {
+				t.Error("SolveSystem() не повернув помилку для виродженої матриці")
+			}
+		})
+	}
+
+	rect := NewComplexMatrix(2, 3)
+	if _, err := rect.Determinant(); err == nil {
+		t.Error("Determinant() прийняв неквадратну матрицю")
+	}
+	if _, err := rect.Inverse(); err == nil {
+		t.Error("Inverse() прийняв неквадратну матрицю")
+	}
+	if _, err := rect.SolveSystem(make([]complex128, 2)); err == nil {
+		t.Error("SolveSystem() прийняв неквадратну матрицю")
+	}
+	if _, err := complexIdentity(2).SolveSystem(make([]complex128, 3)); err == nil {
+		t.Error("SolveSystem() прийняв вектор неправильної довжини")
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package matrixlib
 
 import (
 	"errors"
@@ -19,41 +19,54 @@ func NewMatrix(rows, cols int) *Matrix {
 	return &Matrix{rows, cols, data}
 }
 
-func (m *Matrix) Add(other *Matrix) (*Matrix, error) {
-	if m.rows != other.rows || m.cols != other.cols {
+func (m *Matrix) Dims() (int, int) {
+	return m.rows, m.cols
+}
+
+func (m *Matrix) At(i, j int) float64 {
+	return m.data[i][j]
+}
+
+func (m *Matrix) Set(i, j int, v float64) {
+	m.data[i][j] = v
+}
+
+func (m *Matrix) Add(other MatrixView) (*Matrix, error) {
+	if rows, cols := other.Dims(); m.rows != rows || m.cols != cols {
 		return nil, errors.New("розміри матриць не співпадають")
 	}
 	result := NewMatrix(m.rows, m.cols)
 	for i := 0; i < m.rows; i++ {
 		for j := 0; j < m.cols; j++ {
-			result.data[i][j] = m.data[i][j] + other.data[i][j]
+			result.data[i][j] = m.data[i][j] + other.At(i, j)
 		}
 	}
 	return result, nil
 }
 
-func (m *Matrix) Subtract(other *Matrix) (*Matrix, error) {
-	if m.rows != other.rows || m.cols != other.cols {
+func (m *Matrix) Subtract(other MatrixView) (*Matrix, error) {
+	if rows, cols := other.Dims(); m.rows != rows || m.cols != cols {
 		return nil, errors.New("розміри матриць не співпадають")
 	}
 	result := NewMatrix(m.rows, m.cols)
 	for i := 0; i < m.rows; i++ {
 		for j := 0; j < m.cols; j++ {
-			result.data[i][j] = m.data[i][j] - other.data[i][j]
+			result.data[i][j] = m.data[i][j] - other.At(i, j)
 		}
 	}
 	return result, nil
 }
 
-func (m *Matrix) Multiply(other *Matrix) (*Matrix, error) {
-	if m.cols != other.rows {
+func (m *Matrix) Multiply(other MatrixView) (*Matrix, error) {
+	rows, cols := other.Dims()
+	if m.cols != rows {
 		return nil, errors.New("кількість стовпців першої матриці має дорівнювати кількості рядків другої")
 	}
-	result := NewMatrix(m.rows, other.cols)
+	result := NewMatrix(m.rows, cols)
 	for i := 0; i < m.rows; i++ {
-		for j := 0; j < other.cols; j++ {
+		for j := 0; j < cols; j++ {
 			for k := 0; k < m.cols; k++ {
-				result.data[i][j] += m.data[i][k] * other.data[k][j]
+				result.data[i][j] += m.data[i][k] * other.At(k, j)
 			}
 		}
 	}
@@ -78,6 +91,10 @@ func (m *Matrix) Determinant() (float64, error) {
 }
 
 func (m *Matrix) determinantRecursive(data [][]float64) float64 {
+	// Мінор матриці 1x1 порожній, його визначник дорівнює 1
+	if len(data) == 0 {
+		return 1
+	}
 	if len(data) == 1 {
 		return data[0][0]
 	}
@@ -241,113 +258,3 @@ func (m *Matrix) SolveSystem(b []float64) ([]float64, error) {
 
 	return solution, nil
 }
-
-func main() {
-	fmt.Println("Програма для роботи з матрицями")
-	var rows, cols int
-	fmt.Print("Введіть кількість рядків: ")
-	fmt.Scan(&rows)
-	fmt.Print("Введіть кількість стовпців: ")
-	fmt.Scan(&cols)
-
-	m := NewMatrix(rows, cols)
-	m.Input()
-	fmt.Println("Введена матриця:")
-	m.Print()
-
-	////////////////////////////////////////////
-	fmt.Print("Введіть кількість рядків: ")
-	fmt.Scan(&rows)
-	fmt.Print("Введіть кількість стовпців: ")
-	fmt.Scan(&cols)
-
-	m2 := NewMatrix(rows, cols)
-	m2.Input()
-	fmt.Println("Введена матриця:")
-	m2.Print()
-	////////////////////////////////////////////
-
-	fmt.Println("\nТранспонована матриця:")
-	transposed := m.Transpose()
-	transposed.Print()
-
-	fmt.Println("\nДетермінант першої матриці:")
-	det, err := m.Determinant()
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Printf("%.2f\n", det)
-	}
-
-	fmt.Println("\nОбернута перша матриця:")
-	inverse, err := m.Inverse()
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		inverse.Print()
-	}
-
-	fmt.Println("\nДодавання:")
-	result, err := m.Add(m2)
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		result.Print()
-	}
-
-	fmt.Println("\nВіднімання:")
-	result, err = m.Subtract(m2)
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		result.Print()
-	}
-
-	fmt.Println("\nМноження:")
-	result, err = m.Multiply(m2)
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		result.Print()
-	}
-
-	fmt.Println("\nСортування по рядках:")
-	r := &RowLexicographicSort{m}
-	r.Sort()
-	fmt.Println("Матриця після сортування:")
-	m.Print()
-
-	fmt.Println("\nСортування по стовпцях:")
-	c := &ColumnLexicographicSort{m}
-	c.Sort()
-	fmt.Println("Матриця після сортування:")
-	m.Print()
-
-	fmt.Println("Розвʼязок СЛАР")
-	b := make([]float64, m.rows)
-	fmt.Print("Введіть вектор вільних членів: ")
-	for i := 0; i < m.rows; i++ {
-		fmt.Scan(&b[i])
-	}
-	solution, err := m.SolveSystem(b)
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("Розвʼязок СЛАР:")
-		for i, x := range solution {
-			fmt.Printf("x%d = %.2f\n", i+1, x)
-		}
-
-		checkSolution := make([]float64, m.rows)
-		for i := 0; i < m.rows; i++ {
-			for j := 0; j < m.cols; j++ {
-				checkSolution[i] += m.data[i][j] * solution[j]
-			}
-		}
-		fmt.Println("Перевірка розв'язку:")
-		for i, x := range checkSolution {
-			fmt.Printf("x%d = %.2f\n", i+1, x)
-		}
-	}
-
-}
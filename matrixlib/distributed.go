@@ -0,0 +1,202 @@
+package matrixlib
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"strings"
+	"time"
+)
+
+type RowBlockArgs struct {
+	Start int
+	Rows  IntMatrix
+	B     IntMatrix
+}
+
+type RowBlockReply struct {
+	Start int
+	Rows  IntMatrix
+}
+
+// MatrixWorker is the RPC service run by worker processes.
+type MatrixWorker struct{}
+
+func (w *MatrixWorker) MultiplyRows(args *RowBlockArgs, reply *RowBlockReply) error {
+	reply.Start = args.Start
+	if len(args.Rows) == 0 {
+		return nil
+	}
+	if len(args.Rows[0]) != len(args.B) || len(args.B) == 0 {
+		return errors.New("row block does not match matrix B")
+	}
+	reply.Rows = MultiplyCell(args.Rows, args.B)
+	return nil
+}
+
+// ParseWorkerAddr splits "tcp:host:port" or "unix:/path.sock" into network
+// and address. Addresses without a known prefix are treated as tcp.
+func ParseWorkerAddr(addr string) (string, string) {
+	if network, rest, ok := strings.Cut(addr, ":"); ok && (network == "tcp" || network == "unix") {
+		return network, rest
+	}
+	return "tcp", addr
+}
+
+// WorkerAddr formats the address of a listener in the form accepted by
+// ParseWorkerAddr.
+func WorkerAddr(listener net.Listener) string {
+	return listener.Addr().Network() + ":" + listener.Addr().String()
+}
+
+// ServeWorker serves MatrixWorker on listener until it is closed.
+func ServeWorker(listener net.Listener) error {
+	server := rpc.NewServer()
+	if err := server.Register(&MatrixWorker{}); err != nil {
+		return err
+	}
+	server.Accept(listener)
+	return nil
+}
+
+type blockJob struct {
+	start, end int
+}
+
+type blockResult struct {
+	job  blockJob
+	rows IntMatrix
+	err  error
+}
+
+// DefaultCallTimeout bounds a single call to a worker when
+// MultiplyDistributed is given no timeout.
+const DefaultCallTimeout = 30 * time.Second
+
+// MultiplyDistributed splits matA into blocks of blockSize rows and sends
+// them to the workers. A call that does not complete within timeout, or
+// whose reply does not have the shape of the block, counts as failed. A
+// worker that keeps failing after maxAttempts calls is dropped and its
+// block is handed to the remaining workers.
+func MultiplyDistributed(matA, matB IntMatrix, workers []string, blockSize, maxAttempts int, timeout time.Duration) (IntMatrix, error) {
+	if len(workers) == 0 {
+		return nil, errors.New("no workers given")
+	}
+	if blockSize < 1 {
+		blockSize = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if timeout <= 0 {
+		timeout = DefaultCallTimeout
+	}
+
+	n := len(matA)
+	numBlocks := (n + blockSize - 1) / blockSize
+	jobs := make(chan blockJob, numBlocks)
+	results := make(chan blockResult, numBlocks+len(workers))
+	for start := 0; start < n; start += blockSize {
+		jobs <- blockJob{start, min(start+blockSize, n)}
+	}
+
+	for _, addr := range workers {
+		go runWorkerClient(addr, matA, matB, maxAttempts, timeout, jobs, results)
+	}
+
+	result := make(IntMatrix, n)
+	remaining, alive := numBlocks, len(workers)
+	var lastErr error
+	for remaining > 0 {
+		res := <-results
+		if res.err != nil {
+			lastErr = res.err
+			alive--
+			if alive == 0 {
+				close(jobs)
+				return nil, fmt.Errorf("all workers failed: %w", lastErr)
+			}
+			jobs <- res.job
+			continue
+		}
+		copy(result[res.job.start:res.job.end], res.rows)
+		remaining--
+	}
+	close(jobs)
+	return result, nil
+}
+
+// runWorkerClient takes jobs for a single worker until the queue is closed
+// or the worker fails; the failing job is sent back with the error.
+func runWorkerClient(addr string, matA, matB IntMatrix, maxAttempts int, timeout time.Duration, jobs <-chan blockJob, results chan<- blockResult) {
+	network, address := ParseWorkerAddr(addr)
+	var client *rpc.Client
+	defer func() {
+		if client != nil {
+			client.Close()
+		}
+	}()
+
+	for job := range jobs {
+		args := &RowBlockArgs{Start: job.start, Rows: matA[job.start:job.end], B: matB}
+		var err error
+		for attempt := 0; attempt < maxAttempts; attempt++ {
+			if attempt > 0 {
+				time.Sleep(time.Duration(attempt) * 50 * time.Millisecond)
+			}
+			if client == nil {
+				var conn net.Conn
+				conn, err = net.DialTimeout(network, address, timeout)
+				if err != nil {
+					continue
+				}
+				client = rpc.NewClient(conn)
+			}
+			var reply RowBlockReply
+			err = callWithTimeout(client, args, &reply, timeout)
+			if err == nil {
+				err = checkReply(reply, job, matB)
+			}
+			if err == nil {
+				results <- blockResult{job: job, rows: reply.Rows}
+				break
+			}
+			client.Close()
+			client = nil
+		}
+		if err != nil {
+			results <- blockResult{job: job, err: fmt.Errorf("worker %s: %w", addr, err)}
+			return
+		}
+	}
+}
+
+// callWithTimeout calls MultiplyRows and gives up after timeout; the
+// caller closes the client, which abandons the call.
+func callWithTimeout(client *rpc.Client, args *RowBlockArgs, reply *RowBlockReply, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	call := client.Go("MatrixWorker.MultiplyRows", args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-timer.C:
+		return fmt.Errorf("no reply within %s", timeout)
+	}
+}
+
+// checkReply verifies that a reply holds one row of the width of matB for
+// every row of the job.
+func checkReply(reply RowBlockReply, job blockJob, matB IntMatrix) error {
+	if len(reply.Rows) != job.end-job.start {
+		return fmt.Errorf("reply for rows %d-%d has %d rows", job.start, job.end, len(reply.Rows))
+	}
+	_, cols := matB.Dims()
+	for i, row := range reply.Rows {
+		if len(row) != cols {
+			return fmt.Errorf("reply row %d has %d values, expected %d", job.start+i, len(row), cols)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package matrixlib
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/rpc"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startWorker serves MatrixWorker on a localhost port for the duration of
+// the test and returns its address.
+func startWorker(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go ServeWorker(listener)
+	return WorkerAddr(listener)
+}
+
+// trackingListener remembers accepted connections so a test can cut them.
+type trackingListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+	}
+	return conn, err
+}
+
+func (l *trackingListener) kill() {
+	l.Listener.Close()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, conn := range l.conns {
+		conn.Close()
+	}
+}
+
+// dyingWorker answers `calls` requests and then shuts its listener and all
+// connections down, like a worker process that crashed mid-job.
+type dyingWorker struct {
+	MatrixWorker
+	mu       sync.Mutex
+	calls    int
+	listener *trackingListener
+}
+
+func (w *dyingWorker) MultiplyRows(args *RowBlockArgs, reply *RowBlockReply) error {
+	w.mu.Lock()
+	w.calls--
+	dead := w.calls < 0
+	w.mu.Unlock()
+	if dead {
+		w.listener.kill()
+		return errors.New("worker crashed")
+	}
+	return w.MatrixWorker.MultiplyRows(args, reply)
+}
+
+func startDyingWorker(t *testing.T, calls int) (*dyingWorker, string) {
+	t.Helper()
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := &trackingListener{Listener: inner}
+	t.Cleanup(listener.kill)
+
+	worker := &dyingWorker{calls: calls, listener: listener}
+	server := rpc.NewServer()
+	if err := server.RegisterName("MatrixWorker", worker); err != nil {
+		t.Fatal(err)
+	}
+	go server.Accept(listener)
+	return worker, WorkerAddr(listener)
+}
+
+func (w *dyingWorker) died() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.calls < 0
+}
+
+func TestMultiplyDistributed(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	matA := RandomIntMatrix(rng, 37, 23)
+	matB := RandomIntMatrix(rng, 23, 19)
+	want := MultiplyNaive(matA, matB)
+
+	tests := []struct {
+		name    string
+		workers func(t *testing.T) []string
+	}{
+		{"one worker", func(t *testing.T) []string {
+			return []string{startWorker(t)}
+		}},
+		{"three workers", func(t *testing.T) []string {
+			return []string{startWorker(t), startWorker(t), startWorker(t)}
+		}},
+		{"unreachable worker", func(t *testing.T) []string {
+			return []string{"tcp:127.0.0.1:1", startWorker(t)}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MultiplyDistributed(matA, matB, tt.workers(t), 4, 2, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(want) {
+				t.Fatal("distributed result differs from the serial product")
+			}
+		})
+	}
+}
+
+func TestMultiplyDistributedWorkerDies(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	matA := RandomIntMatrix(rng, 40, 12)
+	matB := RandomIntMatrix(rng, 12, 9)
+
+	// With 40 one-row blocks the dying worker gets past its two calls long
+	// before the healthy one drains the queue.
+	dying, addr := startDyingWorker(t, 2)
+	got, err := MultiplyDistributed(matA, matB, []string{addr, startWorker(t)}, 1, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dying.died() {
+		t.Fatal("the dying worker never crashed")
+	}
+	if !got.Equal(MultiplyNaive(matA, matB)) {
+		t.Fatal("distributed result differs from the serial product")
+	}
+}
+
+// faultyWorker answers every call with reply, or never answers when hang is
+// set, until the test ends.
+type faultyWorker struct {
+	hang    bool
+	reply   func(args *RowBlockArgs) IntMatrix
+	release chan struct{}
+}
+
+func (w *faultyWorker) MultiplyRows(args *RowBlockArgs, reply *RowBlockReply) error {
+	if w.hang {
+		<-w.release
+		return errors.New("released")
+	}
+	reply.Start = args.Start
+	reply.Rows = w.reply(args)
+	return nil
+}
+
+func startFaultyWorker(t *testing.T, w *faultyWorker) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.release = make(chan struct{})
+	t.Cleanup(func() {
+		close(w.release)
+		listener.Close()
+	})
+	server := rpc.NewServer()
+	if err := server.RegisterName("MatrixWorker", w); err != nil {
+		t.Fatal(err)
+	}
+	go server.Accept(listener)
+	return WorkerAddr(listener)
+}
+
+func TestMultiplyDistributedFaultyWorker(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	matA := RandomIntMatrix(rng, 12, 6)
+	matB := RandomIntMatrix(rng, 6, 5)
+	want := MultiplyNaive(matA, matB)
+
+	tests := []struct {
+		name   string
+		worker *faultyWorker
+	}{
+		{"hung", &faultyWorker{hang: true}},
+		{"short reply", &faultyWorker{reply: func(args *RowBlockArgs) IntMatrix {
+			return MultiplyNaive(args.Rows, args.B)[1:]
+		}}},
+		{"long reply", &faultyWorker{reply: func(args *RowBlockArgs) IntMatrix {
+			rows := MultiplyNaive(args.Rows, args.B)
+			return append(rows, rows[0])
+		}}},
+		{"narrow rows", &faultyWorker{reply: func(args *RowBlockArgs) IntMatrix {
+			rows := MultiplyNaive(args.Rows, args.B)
+			rows[0] = rows[0][1:]
+			return rows
+		}}},
+		{"empty reply", &faultyWorker{reply: func(*RowBlockArgs) IntMatrix { return nil }}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faulty, healthy := startFaultyWorker(t, tt.worker), startWorker(t)
+			done := make(chan struct{})
+			var got IntMatrix
+			var err error
+			go func() {
+				defer close(done)
+				got, err = MultiplyDistributed(matA, matB, []string{faulty, healthy}, 2, 2, 100*time.Millisecond)
+			}()
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("MultiplyDistributed did not give up on the faulty worker")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(want) {
+				t.Fatal("distributed result differs from the serial product")
+			}
+
+			_, err = MultiplyDistributed(matA, matB, []string{faulty}, 2, 2, 100*time.Millisecond)
+			if err == nil {
+				t.Fatal("expected an error with only the faulty worker")
+			}
+		})
+	}
+}
+
+func TestMultiplyDistributedAllWorkersFail(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	matA := RandomIntMatrix(rng, 10, 5)
+	matB := RandomIntMatrix(rng, 5, 5)
+
+	_, first := startDyingWorker(t, 1)
+	_, second := startDyingWorker(t, 0)
+	workers := []string{first, second}
+	if _, err := MultiplyDistributed(matA, matB, workers, 2, 2, 0); err == nil {
+		t.Fatal("expected an error when every worker dies")
+	}
+	if _, err := MultiplyDistributed(matA, matB, nil, 2, 2, 0); err == nil {
+		t.Fatal("expected an error without workers")
+	}
+}
@@ -0,0 +1,129 @@
+package matrixlib
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"strings"
+	"testing"
+)
+
+var fuzzDelimiters = []rune{0, ',', ';', '\t'}
+
+// FuzzReadMatrix feeds the same text to the int, float64 and complex128
+// matrix readers. Each must either fail cleanly or return a non-empty
+// rectangular matrix that survives a write/read round trip.
+func FuzzReadMatrix(f *testing.F) {
+	for _, seed := range []string{
+		"1 2 3\n4 5 6\n",
+		"# comment\n1,2\n3,4\n",
+		"-1;0;1\n",
+		"7\t8\n9\t10\n",
+		"\n\n",
+		"1 2\n3\n",
+		"x\n",
+		"1.5 -2e3\n0 4\n",
+		"1+2i -i\n3 2-0.5i\n",
+	} {
+		for d := range fuzzDelimiters {
+			f.Add(seed, uint8(d))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, input string, delim uint8) {
+		opts := ReadOptions{Delimiter: fuzzDelimiters[int(delim)%len(fuzzDelimiters)], Comment: "#"}
+		checkReadIntMatrix(t, input, opts)
+		checkReadDenseMatrix(t, input)
+		checkReadComplexMatrix(t, input)
+	})
+}
+
+func checkReadIntMatrix(t *testing.T, input string, opts ReadOptions) {
+	matrix, err := ReadIntMatrix(strings.NewReader(input), "fuzz", opts)
+	if err != nil {
+		var matrixErr *MatrixError
+		if !errors.As(err, &matrixErr) {
+			t.Fatalf("ReadIntMatrix(%q): error is not a *MatrixError: %v", input, err)
+		}
+		return
+	}
+	rows, cols := matrix.Dims()
+	if rows == 0 || cols == 0 {
+		t.Fatalf("ReadIntMatrix(%q): empty matrix returned without error", input)
+	}
+	for i, row := range matrix {
+		if len(row) != cols {
+			t.Fatalf("ReadIntMatrix(%q): row %d has %d values, expected %d", input, i, len(row), cols)
+		}
+	}
+
+	var b strings.Builder
+	if err := WriteIntMatrix(&b, matrix); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadIntMatrix(strings.NewReader(b.String()), "roundtrip", DefaultReadOptions)
+	if err != nil {
+		t.Fatalf("ReadIntMatrix(%q): round trip: %v", input, err)
+	}
+	if !matrix.Equal(again) {
+		t.Fatalf("ReadIntMatrix(%q): round trip changed the matrix", input)
+	}
+}
+
+func checkReadDenseMatrix(t *testing.T, input string) {
+	m, err := ReadMatrix(strings.NewReader(input))
+	if err != nil {
+		return
+	}
+	if m.rows == 0 || m.cols == 0 {
+		t.Fatalf("ReadMatrix(%q): empty matrix returned without error", input)
+	}
+	var b strings.Builder
+	if err := m.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadMatrix(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("ReadMatrix(%q): round trip: %v", input, err)
+	}
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			a, b := m.At(i, j), again.At(i, j)
+			if a != b && !(math.IsNaN(a) && math.IsNaN(b)) {
+				t.Fatalf("ReadMatrix(%q): round trip changed [%d][%d] from %v to %v", input, i, j, a, b)
+			}
+		}
+	}
+}
+
+func checkReadComplexMatrix(t *testing.T, input string) {
+	m, err := ReadComplexMatrix(strings.NewReader(input))
+	if err != nil {
+		return
+	}
+	if m.rows == 0 || m.cols == 0 {
+		t.Fatalf("ReadComplexMatrix(%q): empty matrix returned without error", input)
+	}
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			if c := m.At(i, j); cmplx.IsNaN(c) || cmplx.IsInf(c) {
+				return
+			}
+		}
+	}
+	var b strings.Builder
+	if err := m.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadComplexMatrix(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("ReadComplexMatrix(%q): round trip: %v", input, err)
+	}
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			if m.At(i, j) != again.At(i, j) {
+				t.Fatalf("ReadComplexMatrix(%q): round trip changed [%d][%d] from %v to %v", input, i, j, m.At(i, j), again.At(i, j))
+			}
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package matrixlib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+func Identity(n int) *Matrix {
+	m := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m.data[i][i] = 1
+	}
+	return m
+}
+
+func Zeros(rows, cols int) *Matrix {
+	return NewMatrix(rows, cols)
+}
+
+func Ones(rows, cols int) *Matrix {
+	return Filled(rows, cols, 1)
+}
+
+func Filled(rows, cols int, value float64) *Matrix {
+	m := NewMatrix(rows, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			m.data[i][j] = value
+		}
+	}
+	return m
+}
+
+func Diagonal(values []float64) *Matrix {
+	m := NewMatrix(len(values), len(values))
+	for i, v := range values {
+		m.data[i][i] = v
+	}
+	return m
+}
+
+// Рівномірний розподіл на [low, high)
+func RandomUniform(rows, cols int, low, high float64, rng *rand.Rand) *Matrix {
+	m := NewMatrix(rows, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			m.data[i][j] = low + (high-low)*rng.Float64()
+		}
+	}
+	return m
+}
+
+// Нормальний розподіл із заданим середнім і стандартним відхиленням
+func RandomNormal(rows, cols int, mean, stddev float64, rng *rand.Rand) *Matrix {
+	m := NewMatrix(rows, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			m.data[i][j] = mean + stddev*rng.NormFloat64()
+		}
+	}
+	return m
+}
+
+// Симетрична додатно визначена матриця Aᵀ·A + n·I
+func RandomSPD(n int, rng *rand.Rand) *Matrix {
+	a := RandomNormal(n, n, 0, 1, rng)
+	m, _ := a.Transpose().Multiply(a)
+	for i := 0; i < n; i++ {
+		m.data[i][i] += float64(n)
+	}
+	return m
+}
+
+// Ортогональна матриця: процес Грама-Шмідта над стовпцями випадкової матриці
+func RandomOrthogonal(n int, rng *rand.Rand) *Matrix {
+	for {
+		a := RandomNormal(n, n, 0, 1, rng)
+		q := NewMatrix(n, n)
+		ok := true
+		for j := 0; j < n && ok; j++ {
+			v := make([]float64, n)
+			for i := 0; i < n; i++ {
+				v[i] = a.data[i][j]
+			}
+			for k := 0; k < j; k++ {
+				dot := 0.0
+				for i := 0; i < n; i++ {
+					dot += q.data[i][k] * a.data[i][j]
+				}
+				for i := 0; i < n; i++ {
+					v[i] -= dot * q.data[i][k]
+				}
+			}
+			norm := 0.0
+			for _, x := range v {
+				norm += x * x
+			}
+			norm = math.Sqrt(norm)
+			if norm < 1e-10 {
+				ok = false
+				break
+			}
+			for i := 0; i < n; i++ {
+				q.data[i][j] = v[i] / norm
+			}
+		}
+		if ok {
+			return q
+		}
+	}
+}
+
+// Матриця Гільберта: h[i][j] = 1 / (i + j + 1)
+func Hilbert(n int) *Matrix {
+	m := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			m.data[i][j] = 1 / float64(i+j+1)
+		}
+	}
+	return m
+}
+
+// Матриця Вандермонда: v[i][j] = x[i]^j
+func Vandermonde(x []float64, cols int) *Matrix {
+	m := NewMatrix(len(x), cols)
+	for i, xi := range x {
+		p := 1.0
+		for j := 0; j < cols; j++ {
+			m.data[i][j] = p
+			p *= xi
+		}
+	}
+	return m
+}
+
+// Матриця Тепліца з першим стовпцем column і першим рядком row
+func Toeplitz(column, row []float64) (*Matrix, error) {
+	if len(column) == 0 || len(row) == 0 || column[0] != row[0] {
+		return nil, errors.New("перший елемент стовпця і рядка має співпадати")
+	}
+	m := NewMatrix(len(column), len(row))
+	for i := range column {
+		for j := range row {
+			if i >= j {
+				m.data[i][j] = column[i-j]
+			} else {
+				m.data[i][j] = row[j-i]
+			}
+		}
+	}
+	return m, nil
+}
+
+// Тридіагональна матриця з піддіагоналлю sub, діагоналлю diag і наддіагоналлю super
+func Tridiagonal(sub, diag, super []float64) (*Matrix, error) {
+	n := len(diag)
+	if len(sub) != n-1 || len(super) != n-1 {
+		return nil, errors.New("довжини діагоналей не відповідають розміру матриці")
+	}
+	m := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m.data[i][i] = diag[i]
+		if i > 0 {
+			m.data[i][i-1] = sub[i-1]
+		}
+		if i < n-1 {
+			m.data[i][i+1] = super[i]
+		}
+	}
+	return m, nil
+}
+
+// ReadMatrix читає матрицю з дійсними елементами, розділеними пробілами.
+func ReadMatrix(r io.Reader) (*Matrix, error) {
+	scanner := bufio.NewScanner(r)
+	var data [][]float64
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		row := make([]float64, len(fields))
+		for j, field := range fields {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("рядок %d: некоректне число %q", line, field)
+			}
+			row[j] = v
+		}
+		if len(data) > 0 && len(row) != len(data[0]) {
+			return nil, fmt.Errorf("рядок %d: очікувалось %d елементів, отримано %d", line, len(data[0]), len(row))
+		}
+		data = append(data, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("матриця порожня")
+	}
+	return &Matrix{len(data), len(data[0]), data}, nil
+}
+
+func (m *Matrix) Write(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			if j > 0 {
+				writer.WriteString(" ")
+			}
+			writer.WriteString(strconv.FormatFloat(m.data[i][j], 'g', -1, 64))
+		}
+		writer.WriteString("\n")
+	}
+	return writer.Flush()
+}
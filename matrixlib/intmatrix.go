@@ -0,0 +1,259 @@
+package matrixlib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrEmptyMatrix = errors.New("matrix is empty")
+	ErrRaggedRow   = errors.New("row length differs from the first row")
+	ErrBadToken    = errors.New("value is not an integer")
+)
+
+// IntMatrix is a matrix of ints stored as rows; it is the representation
+// used by the multiplication kernels. All rows must have the same length.
+type IntMatrix [][]int
+
+func NewIntMatrix(rows, cols int) IntMatrix {
+	matrix := make(IntMatrix, rows)
+	for i := range matrix {
+		matrix[i] = make([]int, cols)
+	}
+	return matrix
+}
+
+// ToIntMatrix returns v itself when it already is an IntMatrix, otherwise
+// a copy of its elements.
+func ToIntMatrix(v View[int]) IntMatrix {
+	if m, ok := v.(IntMatrix); ok {
+		return m
+	}
+	rows, cols := v.Dims()
+	m := NewIntMatrix(rows, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			m[i][j] = v.At(i, j)
+		}
+	}
+	return m
+}
+
+func (m IntMatrix) Dims() (int, int) {
+	if len(m) == 0 {
+		return 0, 0
+	}
+	return len(m), len(m[0])
+}
+
+func (m IntMatrix) At(i, j int) int {
+	return m[i][j]
+}
+
+func (m IntMatrix) Set(i, j int, v int) {
+	m[i][j] = v
+}
+
+// Equal reports whether both matrices have the same shape and elements.
+func (m IntMatrix) Equal(other View[int]) bool {
+	rows, cols := m.Dims()
+	if r, c := other.Dims(); r != rows || c != cols {
+		return false
+	}
+	for i := 0; i < rows; i++ {
+		if len(m[i]) != cols {
+			return false
+		}
+		for j := 0; j < cols; j++ {
+			if m[i][j] != other.At(i, j) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// RandomIntMatrix fills a matrix with values in [-9, 9].
+func RandomIntMatrix(rng *rand.Rand, rows, cols int) IntMatrix {
+	matrix := NewIntMatrix(rows, cols)
+	for i := range matrix {
+		for j := range matrix[i] {
+			matrix[i][j] = rng.Intn(19) - 9
+		}
+	}
+	return matrix
+}
+
+// MatrixError describes where in a matrix file reading failed. Line and
+// Column are 1-based; they are zero when the error is not tied to a position.
+type MatrixError struct {
+	File   string
+	Line   int
+	Column int
+	Token  string
+	Err    error
+}
+
+func (e *MatrixError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, ":%d", e.Column)
+		}
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Err.Error())
+	if e.Token != "" {
+		fmt.Fprintf(&b, " (%q)", e.Token)
+	}
+	return b.String()
+}
+
+func (e *MatrixError) Unwrap() error {
+	return e.Err
+}
+
+// ReadOptions controls how matrix files are tokenized. A zero Delimiter
+// splits rows on any whitespace; lines starting with Comment are skipped.
+type ReadOptions struct {
+	Delimiter rune
+	Comment   string
+}
+
+var DefaultReadOptions = ReadOptions{Comment: "#"}
+
+// ReadIntMatrix reads a matrix of ints; name is only used in errors, which
+// are always *MatrixError.
+func ReadIntMatrix(r io.Reader, name string, opts ReadOptions) (IntMatrix, error) {
+	scanner := bufio.NewScanner(r)
+	var matrix IntMatrix
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || (opts.Comment != "" && strings.HasPrefix(trimmed, opts.Comment)) {
+			continue
+		}
+
+		tokens, columns := splitRow(text, opts.Delimiter)
+		intRow := make([]int, len(tokens))
+		for i, v := range tokens {
+			num, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, &MatrixError{File: name, Line: line, Column: columns[i], Token: v, Err: ErrBadToken}
+			}
+			intRow[i] = num
+		}
+		if len(matrix) > 0 && len(intRow) != len(matrix[0]) {
+			return nil, &MatrixError{
+				File: name,
+				Line: line,
+				Err:  fmt.Errorf("%w: expected %d values, got %d", ErrRaggedRow, len(matrix[0]), len(intRow)),
+			}
+		}
+		matrix = append(matrix, intRow)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &MatrixError{File: name, Line: line, Err: err}
+	}
+	if len(matrix) == 0 {
+		return nil, &MatrixError{File: name, Err: ErrEmptyMatrix}
+	}
+	return matrix, nil
+}
+
+// splitRow returns the tokens of a row together with their 1-based columns.
+func splitRow(text string, delim rune) ([]string, []int) {
+	var tokens []string
+	var columns []int
+	if delim == 0 {
+		start := -1
+		for i, r := range text + " " {
+			if unicode.IsSpace(r) {
+				if start >= 0 {
+					tokens = append(tokens, text[start:i])
+					columns = append(columns, start+1)
+					start = -1
+				}
+			} else if start < 0 {
+				start = i
+			}
+		}
+		return tokens, columns
+	}
+
+	offset := 0
+	for _, field := range strings.Split(text, string(delim)) {
+		token := strings.TrimSpace(field)
+		column := offset + strings.Index(field, token) + 1
+		if token == "" {
+			column = offset + 1
+		}
+		tokens = append(tokens, token)
+		columns = append(columns, column)
+		offset += len(field) + utf8.RuneLen(delim)
+	}
+	return tokens, columns
+}
+
+// ParseDelimiter maps a delimiter name such as "comma" to its rune.
+func ParseDelimiter(name string) (rune, error) {
+	switch name {
+	case "", "space", "whitespace":
+		return 0, nil
+	case ",", "comma":
+		return ',', nil
+	case ";", "semicolon":
+		return ';', nil
+	case "\\t", "tab":
+		return '\t', nil
+	}
+	return 0, fmt.Errorf("unknown delimiter %q", name)
+}
+
+func WriteIntMatrix(w io.Writer, matrix View[int]) error {
+	writer := bufio.NewWriter(w)
+	rows, cols := matrix.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			_, err := writer.WriteString(fmt.Sprintf("%d ", matrix.At(i, j)))
+			if err != nil {
+				return err
+			}
+		}
+		_, err := writer.WriteString("\n")
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func ReadIntMatrixFile(name string, opts ReadOptions) (IntMatrix, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadIntMatrix(file, name, opts)
+}
+
+func WriteIntMatrixFile(name string, matrix View[int]) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return WriteIntMatrix(file, matrix)
+}
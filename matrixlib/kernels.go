@@ -0,0 +1,243 @@
+package matrixlib
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+const (
+	blockTile      = 32
+	strassenCutoff = 64
+)
+
+// Kernel multiplies matA by matB; the caller checks that the inner
+// dimensions agree.
+type Kernel func(matA, matB IntMatrix) IntMatrix
+
+// Kernels lists the available multiplication strategies by name.
+var Kernels = map[string]Kernel{
+	"cell":     MultiplyCell,
+	"pool":     MultiplyPool,
+	"blocked":  MultiplyBlocked,
+	"strassen": MultiplyStrassen,
+}
+
+func KernelNames() []string {
+	var names []string
+	for name := range Kernels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func LookupKernel(name string) (Kernel, error) {
+	kernel, ok := Kernels[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown kernel %q, expected one of %v", name, KernelNames())
+	}
+	return kernel, nil
+}
+
+// MultiplyNaive is the serial triple loop. It is kept out of Kernels and
+// serves as the reference result for the other kernels.
+func MultiplyNaive(matA, matB IntMatrix) IntMatrix {
+	n, m, p := len(matA), len(matA[0]), len(matB[0])
+	result := NewIntMatrix(n, p)
+	for i := 0; i < n; i++ {
+		for j := 0; j < p; j++ {
+			sum := 0
+			for k := 0; k < m; k++ {
+				sum += matA[i][k] * matB[k][j]
+			}
+			result[i][j] = sum
+		}
+	}
+	return result
+}
+
+// MultiplyCell computes every cell of the result in its own goroutine.
+func MultiplyCell(matA, matB IntMatrix) IntMatrix {
+	n, m, p := len(matA), len(matA[0]), len(matB[0])
+	result := NewIntMatrix(n, p)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		for j := 0; j < p; j++ {
+			wg.Add(1)
+			go func(i, j int) {
+				defer wg.Done()
+				sum := 0
+				for k := 0; k < m; k++ {
+					sum += matA[i][k] * matB[k][j]
+				}
+				result[i][j] = sum
+			}(i, j)
+		}
+	}
+	wg.Wait()
+	return result
+}
+
+// MultiplyPool computes whole rows on a fixed pool of
+// runtime.NumCPU() goroutines instead of one goroutine per cell.
+func MultiplyPool(matA, matB IntMatrix) IntMatrix {
+	n, m, p := len(matA), len(matA[0]), len(matB[0])
+	result := NewIntMatrix(n, p)
+
+	rows := make(chan int, n)
+	for i := 0; i < n; i++ {
+		rows <- i
+	}
+	close(rows)
+
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rows {
+				for k := 0; k < m; k++ {
+					a := matA[i][k]
+					for j := 0; j < p; j++ {
+						result[i][j] += a * matB[k][j]
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return result
+}
+
+// MultiplyBlocked multiplies tile by tile so the working set stays
+// in cache; each horizontal band of tiles is computed by its own goroutine.
+func MultiplyBlocked(matA, matB IntMatrix) IntMatrix {
+	n, m, p := len(matA), len(matA[0]), len(matB[0])
+	result := NewIntMatrix(n, p)
+
+	var wg sync.WaitGroup
+	for ii := 0; ii < n; ii += blockTile {
+		wg.Add(1)
+		go func(ii int) {
+			defer wg.Done()
+			iEnd := min(ii+blockTile, n)
+			for kk := 0; kk < m; kk += blockTile {
+				kEnd := min(kk+blockTile, m)
+				for jj := 0; jj < p; jj += blockTile {
+					jEnd := min(jj+blockTile, p)
+					for i := ii; i < iEnd; i++ {
+						for k := kk; k < kEnd; k++ {
+							a := matA[i][k]
+							for j := jj; j < jEnd; j++ {
+								result[i][j] += a * matB[k][j]
+							}
+						}
+					}
+				}
+			}
+		}(ii)
+	}
+	wg.Wait()
+	return result
+}
+
+// MultiplyStrassen pads both matrices to a power-of-two square and
+// applies Strassen's algorithm, falling back to the blocked kernel below
+// strassenCutoff.
+func MultiplyStrassen(matA, matB IntMatrix) IntMatrix {
+	n, m, p := len(matA), len(matA[0]), len(matB[0])
+	size := 1
+	for size < max(n, m, p) {
+		size *= 2
+	}
+	result := strassen(padMatrix(matA, size), padMatrix(matB, size))
+
+	trimmed := make(IntMatrix, n)
+	for i := range trimmed {
+		trimmed[i] = result[i][:p:p]
+	}
+	return trimmed
+}
+
+func padMatrix(matrix IntMatrix, size int) IntMatrix {
+	padded := NewIntMatrix(size, size)
+	for i, row := range matrix {
+		copy(padded[i], row)
+	}
+	return padded
+}
+
+func strassen(a, b IntMatrix) IntMatrix {
+	n := len(a)
+	if n <= strassenCutoff {
+		return MultiplyBlocked(a, b)
+	}
+	h := n / 2
+	a11, a12, a21, a22 := quadrants(a, h)
+	b11, b12, b21, b22 := quadrants(b, h)
+
+	var m [7]IntMatrix
+	var wg sync.WaitGroup
+	products := [7]func() IntMatrix{
+		func() IntMatrix { return strassen(addInt(a11, a22), addInt(b11, b22)) },
+		func() IntMatrix { return strassen(addInt(a21, a22), b11) },
+		func() IntMatrix { return strassen(a11, subInt(b12, b22)) },
+		func() IntMatrix { return strassen(a22, subInt(b21, b11)) },
+		func() IntMatrix { return strassen(addInt(a11, a12), b22) },
+		func() IntMatrix { return strassen(subInt(a21, a11), addInt(b11, b12)) },
+		func() IntMatrix { return strassen(subInt(a12, a22), addInt(b21, b22)) },
+	}
+	for i, product := range products {
+		wg.Add(1)
+		go func(i int, product func() IntMatrix) {
+			defer wg.Done()
+			m[i] = product()
+		}(i, product)
+	}
+	wg.Wait()
+
+	result := NewIntMatrix(n, n)
+	for i := 0; i < h; i++ {
+		for j := 0; j < h; j++ {
+			result[i][j] = m[0][i][j] + m[3][i][j] - m[4][i][j] + m[6][i][j]
+			result[i][j+h] = m[2][i][j] + m[4][i][j]
+			result[i+h][j] = m[1][i][j] + m[3][i][j]
+			result[i+h][j+h] = m[0][i][j] - m[1][i][j] + m[2][i][j] + m[5][i][j]
+		}
+	}
+	return result
+}
+
+func quadrants(matrix IntMatrix, h int) (q11, q12, q21, q22 IntMatrix) {
+	q11, q12, q21, q22 = make(IntMatrix, h), make(IntMatrix, h), make(IntMatrix, h), make(IntMatrix, h)
+	for i := 0; i < h; i++ {
+		q11[i] = matrix[i][:h:h]
+		q12[i] = matrix[i][h:]
+		q21[i] = matrix[i+h][:h:h]
+		q22[i] = matrix[i+h][h:]
+	}
+	return q11, q12, q21, q22
+}
+
+func addInt(a, b IntMatrix) IntMatrix {
+	result := NewIntMatrix(len(a), len(a[0]))
+	for i := range a {
+		for j := range a[i] {
+			result[i][j] = a[i][j] + b[i][j]
+		}
+	}
+	return result
+}
+
+func subInt(a, b IntMatrix) IntMatrix {
+	result := NewIntMatrix(len(a), len(a[0]))
+	for i := range a {
+		for j := range a[i] {
+			result[i][j] = a[i][j] - b[i][j]
+		}
+	}
+	return result
+}
@@ -0,0 +1,242 @@
+package matrixlib
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomVector — вектор довжини n з рівномірним розподілом на [low, high).
+func randomVector(n int, low, high float64, rng *rand.Rand) []float64 {
+	v := make([]float64, n)
+	for i := range v {
+		v[i] = low + (high-low)*rng.Float64()
+	}
+	return v
+}
+
+// propertyTolerance — відносна похибка для властивостей; визначники й
+// обернені матриці накопичують більшу похибку, ніж DefaultTolerance.
+const propertyTolerance = 1e-6
+
+type property struct {
+	name  string
+	check func(rng *rand.Rand, n int, tol float64) error
+}
+
+var properties = []property{
+	{"(AB)ᵀ = BᵀAᵀ", checkTransposeOfProduct},
+	{"(A+B)ᵀ = Aᵀ+Bᵀ", checkTransposeOfSum},
+	{"A·A⁻¹ = I", checkInverse},
+	{"det(AB) = det(A)·det(B)", checkDeterminantProduct},
+	{"det(Aᵀ) = det(A)", checkDeterminantTranspose},
+	{"A·SolveSystem(A, b) = b", checkSolveSystem},
+	{"QᵀQ = I для ортогональної Q", checkOrthogonal},
+	{"структуровані SolveSystem = щільний SolveSystem", checkStructuredSolvers},
+}
+
+func checkTransposeOfProduct(rng *rand.Rand, n int, tol float64) error {
+	a := RandomUniform(n, n+1, -10, 10, rng)
+	b := RandomUniform(n+1, n, -10, 10, rng)
+	ab, err := a.Multiply(b)
+	if err != nil {
+		return err
+	}
+	btat, err := b.Transpose().Multiply(a.Transpose())
+	if err != nil {
+		return err
+	}
+	if !ab.Transpose().AlmostEqual(btat, tol) {
+		return fmt.Errorf("розмір %d: (AB)ᵀ != BᵀAᵀ", n)
+	}
+	return nil
+}
+
+func checkTransposeOfSum(rng *rand.Rand, n int, tol float64) error {
+	a := RandomUniform(n, n+2, -10, 10, rng)
+	b := RandomUniform(n, n+2, -10, 10, rng)
+	sum, err := a.Add(b)
+	if err != nil {
+		return err
+	}
+	sumT, err := a.Transpose().Add(b.Transpose())
+	if err != nil {
+		return err
+	}
+	if !sum.Transpose().AlmostEqual(sumT, tol) {
+		return fmt.Errorf("розмір %d: (A+B)ᵀ != Aᵀ+Bᵀ", n)
+	}
+	return nil
+}
+
+func checkInverse(rng *rand.Rand, n int, tol float64) error {
+	a := RandomSPD(n, rng)
+	inv, err := a.Inverse()
+	if err != nil {
+		return err
+	}
+	product, err := a.Multiply(inv)
+	if err != nil {
+		return err
+	}
+	if !product.AlmostEqual(Identity(n), tol) {
+		return fmt.Errorf("розмір %d: A·A⁻¹ != I", n)
+	}
+	return nil
+}
+
+func checkDeterminantProduct(rng *rand.Rand, n int, tol float64) error {
+	a := RandomUniform(n, n, -3, 3, rng)
+	b := RandomUniform(n, n, -3, 3, rng)
+	ab, err := a.Multiply(b)
+	if err != nil {
+		return err
+	}
+	detA, _ := a.Determinant()
+	detB, _ := b.Determinant()
+	detAB, _ := ab.Determinant()
+	if !AlmostEqual(detAB, detA*detB, tol) {
+		return fmt.Errorf("розмір %d: det(AB) = %g, det(A)·det(B) = %g", n, detAB, detA*detB)
+	}
+	return nil
+}
+
+func checkDeterminantTranspose(rng *rand.Rand, n int, tol float64) error {
+	a := RandomUniform(n, n, -3, 3, rng)
+	det, _ := a.Determinant()
+	detT, _ := a.Transpose().Determinant()
+	if !AlmostEqual(det, detT, tol) {
+		return fmt.Errorf("розмір %d: det(A) = %g, det(Aᵀ) = %g", n, det, detT)
+	}
+	return nil
+}
+
+func checkSolveSystem(rng *rand.Rand, n int, tol float64) error {
+	a := RandomSPD(n, rng)
+	b := randomVector(n, -10, 10, rng)
+	x, err := a.SolveSystem(b)
+	if err != nil {
+		return err
+	}
+	ax := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			ax[i] += a.At(i, j) * x[j]
+		}
+	}
+	if !VectorsAlmostEqual(ax, b, tol) {
+		return fmt.Errorf("розмір %d: A·x != b", n)
+	}
+	return nil
+}
+
+func checkOrthogonal(rng *rand.Rand, n int, tol float64) error {
+	q := RandomOrthogonal(n, rng)
+	qtq, err := q.Transpose().Multiply(q)
+	if err != nil {
+		return err
+	}
+	if !qtq.AlmostEqual(Identity(n), tol) {
+		return fmt.Errorf("розмір %d: QᵀQ != I", n)
+	}
+	return nil
+}
+
+func randomStructured(rng *rand.Rand, n int) []LinearSolver {
+	lower := NewTriangularMatrix(n, true)
+	upper := NewTriangularMatrix(n, false)
+	symmetric := NewSymmetricPackedMatrix(n)
+	spd := RandomSPD(n, rng)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			lower.Set(i, j, rng.Float64()+float64(n))
+			upper.Set(j, i, rng.Float64()+float64(n))
+			symmetric.Set(i, j, spd.At(i, j))
+		}
+	}
+
+	sub := randomVector(n-1, -1, 1, rng)
+	diag := randomVector(n, 3, 4, rng)
+	super := randomVector(n-1, -1, 1, rng)
+	tridiagonal, _ := NewTridiagonalMatrix(sub, diag, super)
+
+	kl, ku := min(2, n-1), min(1, n-1)
+	banded, _ := NewBandedMatrix(n, kl, ku)
+	for i := 0; i < n; i++ {
+		for j := max(0, i-kl); j <= min(n-1, i+ku); j++ {
+			banded.Set(i, j, rng.Float64()*10-5)
+		}
+	}
+	return []LinearSolver{lower, upper, symmetric, tridiagonal, banded}
+}
+
+func checkStructuredSolvers(rng *rand.Rand, n int, tol float64) error {
+	b := randomVector(n, -10, 10, rng)
+	for _, a := range randomStructured(rng, n) {
+		want, err := Dense(a).SolveSystem(b)
+		if err != nil {
+			continue
+		}
+		got, err := a.SolveSystem(b)
+		if err != nil {
+			return fmt.Errorf("розмір %d, %T: %v", n, a, err)
+		}
+		if !VectorsAlmostEqual(got, want, tol) {
+			return fmt.Errorf("розмір %d, %T: %v != %v", n, a, got, want)
+		}
+	}
+	return nil
+}
+
+// TestProperties перевіряє кожну властивість на випадкових матрицях розміром
+// від 1 до 6.
+func TestProperties(t *testing.T) {
+	trials := 50
+	if testing.Short() {
+		trials = 10
+	}
+	for _, p := range properties {
+		t.Run(p.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			for trial := 0; trial < trials; trial++ {
+				n := 1 + rng.Intn(6)
+				if err := p.check(rng, n, propertyTolerance); err != nil {
+					t.Fatalf("випробування %d: %v", trial, err)
+				}
+			}
+		})
+	}
+}
+
+func TestAlmostEqual(t *testing.T) {
+	tests := []struct {
+		a, b, tol float64
+		want      bool
+	}{
+		{1, 1, 0, true},
+		{1, 1 + 1e-12, DefaultTolerance, true},
+		{1e12, 1e12 + 1, DefaultTolerance, true},
+		{1, 1.001, DefaultTolerance, false},
+		{0, 1e-10, DefaultTolerance, true},
+		{0, 1e-8, DefaultTolerance, false},
+		{-1, 1, 1, false},
+		{math.NaN(), math.NaN(), 1, false},
+	}
+	for _, tt := range tests {
+		if got := AlmostEqual(tt.a, tt.b, tt.tol); got != tt.want {
+			t.Errorf("AlmostEqual(%g, %g, %g) = %v, очікувалось %v", tt.a, tt.b, tt.tol, got, tt.want)
+		}
+	}
+
+	m := Identity(2)
+	if !m.AlmostEqual(Diagonal([]float64{1, 1 + 1e-12}), DefaultTolerance) {
+		t.Error("Identity(2) відрізняється від майже рівної діагональної матриці")
+	}
+	if m.AlmostEqual(Identity(3), DefaultTolerance) {
+		t.Error("матриці різних розмірів вважаються рівними")
+	}
+	if VectorsAlmostEqual([]float64{1}, []float64{1, 2}, DefaultTolerance) {
+		t.Error("вектори різної довжини вважаються рівними")
+	}
+}
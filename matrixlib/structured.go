@@ -0,0 +1,347 @@
+package matrixlib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MatrixView — спільний інтерфейс для щільних і структурованих матриць.
+type MatrixView = View[float64]
+
+// LinearSolver — матриця, що вміє розв'язувати СЛАР власним методом.
+type LinearSolver interface {
+	MatrixView
+	SolveSystem(b []float64) ([]float64, error)
+}
+
+// Dense копіює будь-яку матрицю у щільне представлення.
+func Dense(v MatrixView) *Matrix {
+	rows, cols := v.Dims()
+	m := NewMatrix(rows, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			m.data[i][j] = v.At(i, j)
+		}
+	}
+	return m
+}
+
+func checkRightSide(n int, b []float64) error {
+	if len(b) != n {
+		return errors.New("довжина вектора вільних членів не відповідає розміру матриці")
+	}
+	return nil
+}
+
+// TriangularMatrix зберігає лише нижній або верхній трикутник по рядках.
+type TriangularMatrix struct {
+	n     int
+	lower bool
+	data  []float64
+}
+
+func NewTriangularMatrix(n int, lower bool) *TriangularMatrix {
+	return &TriangularMatrix{n, lower, make([]float64, n*(n+1)/2)}
+}
+
+func (t *TriangularMatrix) index(i, j int) (int, bool) {
+	if t.lower {
+		if j > i {
+			return 0, false
+		}
+		return i*(i+1)/2 + j, true
+	}
+	if j < i {
+		return 0, false
+	}
+	return i*t.n - i*(i-1)/2 + (j - i), true
+}
+
+func (t *TriangularMatrix) Dims() (int, int) {
+	return t.n, t.n
+}
+
+func (t *TriangularMatrix) At(i, j int) float64 {
+	if k, ok := t.index(i, j); ok {
+		return t.data[k]
+	}
+	return 0
+}
+
+// Set панікує, якщо ненульове значення записується поза трикутником.
+func (t *TriangularMatrix) Set(i, j int, v float64) {
+	k, ok := t.index(i, j)
+	if !ok {
+		if v != 0 {
+			panic(fmt.Sprintf("елемент [%d][%d] поза трикутником", i, j))
+		}
+		return
+	}
+	t.data[k] = v
+}
+
+func (t *TriangularMatrix) Determinant() float64 {
+	det := 1.0
+	for i := 0; i < t.n; i++ {
+		det *= t.At(i, i)
+	}
+	return det
+}
+
+// Пряма підстановка для нижньої та зворотна для верхньої трикутної матриці
+func (t *TriangularMatrix) SolveSystem(b []float64) ([]float64, error) {
+	if err := checkRightSide(t.n, b); err != nil {
+		return nil, err
+	}
+	x := make([]float64, t.n)
+	for step := 0; step < t.n; step++ {
+		i := step
+		if !t.lower {
+			i = t.n - 1 - step
+		}
+		diag := t.At(i, i)
+		if math.Abs(diag) < 1e-10 {
+			return nil, errors.New("система не має єдиного розв'язку")
+		}
+		sum := b[i]
+		if t.lower {
+			for j := 0; j < i; j++ {
+				sum -= t.At(i, j) * x[j]
+			}
+		} else {
+			for j := i + 1; j < t.n; j++ {
+				sum -= t.At(i, j) * x[j]
+			}
+		}
+		x[i] = sum / diag
+	}
+	return x, nil
+}
+
+// TridiagonalMatrix зберігає піддіагональ, діагональ і наддіагональ.
+type TridiagonalMatrix struct {
+	n                int
+	sub, diag, super []float64
+}
+
+func NewTridiagonalMatrix(sub, diag, super []float64) (*TridiagonalMatrix, error) {
+	n := len(diag)
+	if n == 0 || len(sub) != n-1 || len(super) != n-1 {
+		return nil, errors.New("довжини діагоналей не відповідають розміру матриці")
+	}
+	return &TridiagonalMatrix{n, sub, diag, super}, nil
+}
+
+func (t *TridiagonalMatrix) Dims() (int, int) {
+	return t.n, t.n
+}
+
+func (t *TridiagonalMatrix) At(i, j int) float64 {
+	switch j - i {
+	case -1:
+		return t.sub[j]
+	case 0:
+		return t.diag[i]
+	case 1:
+		return t.super[i]
+	}
+	return 0
+}
+
+// Метод прогонки (алгоритм Томаса), без вибору головного елемента
+func (t *TridiagonalMatrix) SolveSystem(b []float64) ([]float64, error) {
+	if err := checkRightSide(t.n, b); err != nil {
+		return nil, err
+	}
+	c := make([]float64, t.n)
+	d := make([]float64, t.n)
+	for i := 0; i < t.n; i++ {
+		denom := t.diag[i]
+		if i > 0 {
+			denom -= t.sub[i-1] * c[i-1]
+		}
+		if math.Abs(denom) < 1e-10 {
+			return nil, errors.New("система не має єдиного розв'язку")
+		}
+		if i < t.n-1 {
+			c[i] = t.super[i] / denom
+		}
+		d[i] = b[i]
+		if i > 0 {
+			d[i] -= t.sub[i-1] * d[i-1]
+		}
+		d[i] /= denom
+	}
+
+	x := make([]float64, t.n)
+	for i := t.n - 1; i >= 0; i-- {
+		x[i] = d[i]
+		if i < t.n-1 {
+			x[i] -= c[i] * x[i+1]
+		}
+	}
+	return x, nil
+}
+
+// BandedMatrix має kl ненульових піддіагоналей і ku наддіагоналей;
+// рядок i зберігає стовпці i-kl..i+ku.
+type BandedMatrix struct {
+	n, kl, ku int
+	data      [][]float64
+}
+
+func NewBandedMatrix(n, kl, ku int) (*BandedMatrix, error) {
+	if n < 1 {
+		return nil, errors.New("розмір матриці має бути додатним")
+	}
+	if kl < 0 || kl > n-1 || ku < 0 || ku > n-1 {
+		return nil, fmt.Errorf("ширина смуги kl=%d, ku=%d має бути від 0 до %d", kl, ku, n-1)
+	}
+	data := make([][]float64, n)
+	for i := range data {
+		data[i] = make([]float64, kl+ku+1)
+	}
+	return &BandedMatrix{n, kl, ku, data}, nil
+}
+
+func (b *BandedMatrix) Dims() (int, int) {
+	return b.n, b.n
+}
+
+func (b *BandedMatrix) At(i, j int) float64 {
+	if j-i < -b.kl || j-i > b.ku {
+		return 0
+	}
+	return b.data[i][j-i+b.kl]
+}
+
+// Set панікує, якщо ненульове значення записується поза смугою.
+func (b *BandedMatrix) Set(i, j int, v float64) {
+	if j-i < -b.kl || j-i > b.ku {
+		if v != 0 {
+			panic(fmt.Sprintf("елемент [%d][%d] поза смугою", i, j))
+		}
+		return
+	}
+	b.data[i][j-i+b.kl] = v
+}
+
+// LU-розклад смугової матриці з вибором головного елемента. Перестановки
+// рядків розширюють верхню смугу до kl+ku, тому робоча копія ширша.
+func (b *BandedMatrix) SolveSystem(rhs []float64) ([]float64, error) {
+	if err := checkRightSide(b.n, rhs); err != nil {
+		return nil, err
+	}
+	n, kl, upper := b.n, b.kl, b.kl+b.ku
+	work := make([][]float64, n)
+	for i := range work {
+		work[i] = make([]float64, kl+upper+1)
+		copy(work[i], b.data[i])
+	}
+	at := func(i, j int) *float64 {
+		return &work[i][j-i+kl]
+	}
+	x := append([]float64(nil), rhs...)
+
+	for k := 0; k < n; k++ {
+		last := min(n-1, k+kl)
+		maxRow := k
+		for i := k + 1; i <= last; i++ {
+			if math.Abs(*at(i, k)) > math.Abs(*at(maxRow, k)) {
+				maxRow = i
+			}
+		}
+		if math.Abs(*at(maxRow, k)) < 1e-10 {
+			return nil, errors.New("система не має єдиного розв'язку")
+		}
+		lastCol := min(n-1, k+upper)
+		if maxRow != k {
+			for j := k; j <= lastCol; j++ {
+				*at(k, j), *at(maxRow, j) = *at(maxRow, j), *at(k, j)
+			}
+			x[k], x[maxRow] = x[maxRow], x[k]
+		}
+		for i := k + 1; i <= last; i++ {
+			factor := *at(i, k) / *at(k, k)
+			for j := k; j <= lastCol; j++ {
+				*at(i, j) -= factor * *at(k, j)
+			}
+			x[i] -= factor * x[k]
+		}
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j <= min(n-1, i+upper); j++ {
+			x[i] -= *at(i, j) * x[j]
+		}
+		x[i] /= *at(i, i)
+	}
+	return x, nil
+}
+
+// SymmetricPackedMatrix зберігає нижній трикутник симетричної матриці.
+type SymmetricPackedMatrix struct {
+	n    int
+	data []float64
+}
+
+func NewSymmetricPackedMatrix(n int) *SymmetricPackedMatrix {
+	return &SymmetricPackedMatrix{n, make([]float64, n*(n+1)/2)}
+}
+
+func (s *SymmetricPackedMatrix) index(i, j int) int {
+	if j > i {
+		i, j = j, i
+	}
+	return i*(i+1)/2 + j
+}
+
+func (s *SymmetricPackedMatrix) Dims() (int, int) {
+	return s.n, s.n
+}
+
+func (s *SymmetricPackedMatrix) At(i, j int) float64 {
+	return s.data[s.index(i, j)]
+}
+
+// Set змінює одночасно елементи [i][j] і [j][i].
+func (s *SymmetricPackedMatrix) Set(i, j int, v float64) {
+	s.data[s.index(i, j)] = v
+}
+
+// Метод Холецького для додатно визначених матриць; для інших — метод Гауса
+func (s *SymmetricPackedMatrix) SolveSystem(b []float64) ([]float64, error) {
+	if err := checkRightSide(s.n, b); err != nil {
+		return nil, err
+	}
+	l := NewTriangularMatrix(s.n, true)
+	for i := 0; i < s.n; i++ {
+		for j := 0; j <= i; j++ {
+			sum := s.At(i, j)
+			for k := 0; k < j; k++ {
+				sum -= l.At(i, k) * l.At(j, k)
+			}
+			if i == j {
+				if sum <= 0 {
+					return Dense(s).SolveSystem(b)
+				}
+				l.Set(i, i, math.Sqrt(sum))
+			} else {
+				l.Set(i, j, sum/l.At(j, j))
+			}
+		}
+	}
+
+	y, err := l.SolveSystem(b)
+	if err != nil {
+		return nil, err
+	}
+	lt := NewTriangularMatrix(s.n, false)
+	for i := 0; i < s.n; i++ {
+		for j := i; j < s.n; j++ {
+			lt.Set(i, j, l.At(j, i))
+		}
+	}
+	return lt.SolveSystem(y)
+}
@@ -0,0 +1,45 @@
+package matrixlib
+
+import "math"
+
+// DefaultTolerance — типова відносна похибка для порівняння результатів.
+const DefaultTolerance = 1e-9
+
+// AlmostEqual порівнює числа з відносною похибкою tol; для чисел, близьких до
+// нуля, похибка вважається абсолютною.
+func AlmostEqual(a, b, tol float64) bool {
+	diff := math.Abs(a - b)
+	if diff <= tol {
+		return true
+	}
+	return diff <= tol*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// AlmostEqual перевіряє, що матриці мають однакові розміри і поелементно
+// рівні з точністю tol.
+func (m *Matrix) AlmostEqual(other MatrixView, tol float64) bool {
+	if rows, cols := other.Dims(); m.rows != rows || m.cols != cols {
+		return false
+	}
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			if !AlmostEqual(m.data[i][j], other.At(i, j), tol) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// VectorsAlmostEqual порівнює вектори поелементно з точністю tol.
+func VectorsAlmostEqual(a, b []float64, tol float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !AlmostEqual(a[i], b[i], tol) {
+			return false
+		}
+	}
+	return true
+}
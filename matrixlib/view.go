@@ -0,0 +1,28 @@
+// Package matrixlib holds the matrix types shared by the matrix and matrix2
+// programs: the View and Mutable interfaces, the [][]int matrices with their
+// multiplication kernels, and the float64 and complex128 dense and
+// structured matrices.
+package matrixlib
+
+// View is the read-only matrix interface implemented by every matrix type
+// in this package.
+type View[T any] interface {
+	Dims() (rows, cols int)
+	At(i, j int) T
+}
+
+// Mutable is a View whose elements can be changed in place.
+type Mutable[T any] interface {
+	View[T]
+	Set(i, j int, v T)
+}
+
+var (
+	_ Mutable[int]        = IntMatrix(nil)
+	_ Mutable[float64]    = (*Matrix)(nil)
+	_ Mutable[complex128] = (*ComplexMatrix)(nil)
+	_ Mutable[float64]    = (*TriangularMatrix)(nil)
+	_ View[float64]       = (*TridiagonalMatrix)(nil)
+	_ Mutable[float64]    = (*BandedMatrix)(nil)
+	_ Mutable[float64]    = (*SymmetricPackedMatrix)(nil)
+)
@@ -1,3 +1,8 @@
+//go:build ignore
+
+// The basic version of memory_man.go; run it on its own with
+// go run memory_man_basic.go.
+
 package main
 
 import (
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const usage = `Usage: go run *.go <command> [flags] [args]
+
+Commands:
+  sort       sort routes by any field (-by stops:desc,distance)
+  filter     filter routes with a query (-query 'start = "Station A" and distance < 20')
+  top        k best routes by a field, streamed without loading the file (-k, -by)
+  bench-index  benchmark streaming reads, indexed filters and top-k queries
+  stats      distance, stop and per-station statistics (-group-by start|end)
+  maxstops   routes with the maximum number of stops
+  avg-below  routes whose average stop length is below -x km
+  path       shortest path between two stations
+  journey    k-best multi-leg journeys between two stations
+  connectivity  components, cut stations, bridges and centrality of the network
+  export     write the network as Graphviz DOT, GraphML or GeoJSON (-to)
+  fare       price routes, or journeys between two stations, with -rules fares.json
+  timetable  earliest arrival between two stations from stop_times.csv
+  import-gtfs convert a GTFS feed (zip or directory) to routes
+  stations   nearest stations or stations within a radius of a point
+  check-distances  compare route distances with station coordinates
+  store      add, update, delete and export routes in an embedded store (routes.db)
+  serve      HTTP JSON API over the routes file (-addr :8080)
+  diff       added, removed and modified routes between two files (-format text|json|patch)
+  validate   report invalid rows of a routes file
+  report     the original summary (default when no command is given)
+
+Common flags: -file routes.csv|routes.db|feed.zip, -format table|csv|json|ndjson, -delim, -lenient
+Run a command with -h to see all of its flags.`
+
+// commonOptions holds the flags shared by every routes command.
+type commonOptions struct {
+	file    *string
+	format  *string
+	delim   *string
+	lenient *bool
+}
+
+func addCommonFlags(fs *flag.FlagSet) *commonOptions {
+	return &commonOptions{
+		file:    fs.String("file", "routes.csv", "routes file: CSV, route store (.db) or GTFS feed"),
+		format:  fs.String("format", "table", "output format: "+strings.Join(outputFormats, ", ")),
+		delim:   fs.String("delim", "auto", "field delimiter: auto, comma, semicolon, tab or pipe"),
+		lenient: fs.Bool("lenient", false, "skip invalid rows instead of failing"),
+	}
+}
+
+// load reads the routes file named by -file; see loadRoutes.
+func (o *commonOptions) load() ([]Route, error) {
+	if err := checkFormat(*o.format); err != nil {
+		return nil, err
+	}
+	delim, err := parseDelimiter(*o.delim)
+	if err != nil {
+		return nil, err
+	}
+	return loadRoutes(*o.file, ReadOptions{Delimiter: delim, Lenient: *o.lenient})
+}
+
+// loadRoutes reads a routes CSV file, the route store when file ends in
+// .db, or imports it when file names a GTFS feed; skipped rows are reported
+// on stderr.
+func loadRoutes(file string, opts ReadOptions) ([]Route, error) {
+	if isStorePath(file) {
+		return readRoutesFromStore(file)
+	}
+	if isGTFSPath(file) {
+		return importGTFSFromPath(file, GTFSOptions{})
+	}
+	routes, rowErrors, err := readRoutesFromFileWithOptions(file, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, rowErr := range rowErrors {
+		fmt.Fprintln(os.Stderr, "skipped", rowErr)
+	}
+	return routes, nil
+}
+
+// eachRoute calls fn for every route of file, accepting the same inputs as
+// loadRoutes. CSV files are streamed instead of read into memory; skipped
+// rows are reported on stderr once the file has been read.
+func eachRoute(file string, opts ReadOptions, fn func(Route) error) error {
+	if isStorePath(file) || isGTFSPath(file) {
+		routes, err := loadRoutes(file, opts)
+		if err != nil {
+			return err
+		}
+		for _, r := range routes {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rowErrors, err := streamRoutes(f, opts, fn)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	for _, rowErr := range rowErrors {
+		fmt.Fprintln(os.Stderr, "skipped", rowErr)
+	}
+	return nil
+}
+
+// parseOrderKeys parses "field[:asc|:desc],..." into sort keys.
+func parseOrderKeys(s string) ([]OrderKey, error) {
+	var keys []OrderKey
+	for _, part := range strings.Split(s, ",") {
+		field, dir, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if !isField(field) {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		switch dir {
+		case "", "asc":
+			keys = append(keys, OrderKey{Field: field})
+		case "desc":
+			keys = append(keys, OrderKey{Field: field, Desc: true})
+		default:
+			return nil, fmt.Errorf("unknown direction %q", dir)
+		}
+	}
+	return keys, nil
+}
+
+func runSort(args []string) {
+	fs := flag.NewFlagSet("sort", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	by := fs.String("by", "distance", "comma-separated fields with optional :desc (start, end, stops, distance, avg)")
+	desc := fs.Bool("desc", false, "reverse the direction of every sort key")
+	fs.Parse(args)
+
+	keys, err := parseOrderKeys(*by)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if *desc {
+		for i := range keys {
+			keys[i].Desc = !keys[i].Desc
+		}
+	}
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	q := &Query{OrderBy: keys}
+	if err := writeRoutes(os.Stdout, q.Apply(routes), *opts.format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
+
+func runFilter(args []string) {
+	fs := flag.NewFlagSet("filter", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	query := fs.String("query", "", `query, e.g. 'start = "Station A" and distance < 20 order by stops desc limit 5'`)
+	start := fs.String("start", "", "shortcut for -query 'start = \"...\"'")
+	fs.Parse(args)
+
+	q := &Query{}
+	var err error
+	switch {
+	case *query != "":
+		q, err = parseQuery(*query)
+		if err != nil {
+			fmt.Println("Error parsing query:", err)
+			return
+		}
+	case *start != "":
+		q.Where = comparison{field: "start", op: "=", str: *start}
+	}
+
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	if err := writeRoutes(os.Stdout, q.Apply(routes), *opts.format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
+
+func runMaxStops(args []string) {
+	fs := flag.NewFlagSet("maxstops", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	fs.Parse(args)
+
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	if err := writeRoutes(os.Stdout, findRoutesWithMaxStops(routes), *opts.format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
+
+func runAvgBelow(args []string) {
+	fs := flag.NewFlagSet("avg-below", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	x := fs.Float64("x", 5.0, "average stop length threshold in km")
+	count := fs.Bool("count", false, "print only the number of matching routes")
+	fs.Parse(args)
+
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	if *count {
+		fmt.Println(countRoutesWithAvgStopLengthLessThanX(routes, *x))
+		return
+	}
+	q := &Query{Where: comparison{field: "avg", op: "<", number: *x}}
+	if err := writeRoutes(os.Stdout, q.Apply(routes), *opts.format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
+
+func runPath(args []string) {
+	fs := flag.NewFlagSet("path", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	weightName := fs.String("weight", "distance", "edge weight: distance, stops or hops")
+	algorithm := fs.String("algo", "auto", "algorithm: auto, dijkstra, bellman-ford or astar")
+	undirected := fs.Bool("undirected", false, "allow travelling routes from end to start")
+	stationsFile := fs.String("stations", "", "stations file; gives -algo astar a straight-line heuristic for -weight distance")
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fmt.Println("Usage: go run *.go path [flags] <from> <to>")
+		return
+	}
+	weight, err := weightFuncByName(*weightName)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+
+	var heuristic func(string) float64
+	if *stationsFile != "" && *weightName == "distance" {
+		stations, err := readStationsFromFile(*stationsFile)
+		if err != nil {
+			fmt.Println("Error reading file:", err)
+			return
+		}
+		heuristic = haversineHeuristic(stations, routes, fs.Arg(1))
+	}
+
+	g := buildStationGraph(routes, weight, *undirected)
+	path, err := shortestPath(g, fs.Arg(0), fs.Arg(1), *algorithm, heuristic)
+	if err != nil {
+		fmt.Println("Error finding path:", err)
+		return
+	}
+	if err := writePaths(os.Stdout, []Path{path}, *opts.format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
+
+// writePaths prints itineraries as text for table format, otherwise as
+// JSON; csv and ndjson list the legs of each itinerary.
+func writePaths(w io.Writer, paths []Path, format string) error {
+	switch format {
+	case "table":
+		for i, p := range paths {
+			if len(paths) > 1 {
+				fmt.Fprintf(w, "%d. %d transfers, ", i+1, len(p.Legs)-1)
+			}
+			fmt.Fprint(w, formatPath(p))
+		}
+		return nil
+	case "json":
+		return writeJSON(w, paths)
+	}
+	var legs []Route
+	for _, p := range paths {
+		legs = append(legs, p.Legs...)
+	}
+	return writeRoutes(w, legs, format)
+}
+
+func runValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	delimName := fs.String("delim", "auto", "field delimiter: auto, comma, semicolon, tab or pipe")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Println("Usage: go run *.go validate [-delim d] <routes.csv>")
+		return
+	}
+	delim, err := parseDelimiter(*delimName)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	routes, rowErrors, err := readRoutesFromFileWithOptions(fs.Arg(0), ReadOptions{Delimiter: delim, Lenient: true})
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	for _, rowErr := range rowErrors {
+		fmt.Println(rowErr)
+	}
+	fmt.Printf("%d valid routes, %d invalid rows\n", len(routes), len(rowErrors))
+}
+
+// runReport prints the original summary of the routes file.
+func runReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	file := fs.String("file", "routes.csv", "routes file: CSV, route store (.db) or GTFS feed")
+	query := fs.String("query", "", "filter query, see the filter command")
+	x := fs.Float64("x", 5.0, "average stop length threshold in km")
+	startStation := fs.String("start", "Station A", "start station to list routes from")
+	fs.Parse(args)
+
+	if *query != "" {
+		runFilter([]string{"-file", *file, "-query", *query})
+		return
+	}
+
+	routes, err := loadRoutes(*file, ReadOptions{})
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+
+	sortRoutesByDistance(routes)
+	fmt.Println("Sorted Routes by Distance:", routes)
+
+	count := countRoutesWithAvgStopLengthLessThanX(routes, *x)
+	fmt.Printf("Number of routes with average stop length less than %.2f km: %d\n", *x, count)
+
+	filteredRoutes := filterRoutesByStartStation(routes, *startStation)
+	fmt.Printf("Routes starting from %s: %v\n", *startStation, filteredRoutes)
+
+	maxStopRoutes := findRoutesWithMaxStops(routes)
+	fmt.Println("Routes with maximum stops:", maxStopRoutes)
+}
+
+var commands = map[string]func(args []string){
+	"sort":            runSort,
+	"filter":          runFilter,
+	"top":             runTop,
+	"bench-index":     runBenchIndex,
+	"stats":           runStats,
+	"maxstops":        runMaxStops,
+	"avg-below":       runAvgBelow,
+	"path":            runPath,
+	"journey":         runJourney,
+	"connectivity":    runConnectivity,
+	"export":          runExport,
+	"fare":            runFare,
+	"timetable":       runTimetable,
+	"import-gtfs":     runImportGTFS,
+	"stations":        runStations,
+	"check-distances": runCheckDistances,
+	"store":           runStore,
+	"serve":           runServe,
+	"diff":            runDiff,
+	"validate":        runValidate,
+	"report":          runReport,
+}
+
+func main() {
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		if len(os.Args) > 1 && (os.Args[1] == "-h" || os.Args[1] == "--help") {
+			fmt.Println(usage)
+			return
+		}
+		runReport(os.Args[1:])
+		return
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Printf("Unknown command %q\n\n%s\n", os.Args[1], usage)
+		return
+	}
+	run(os.Args[2:])
+}
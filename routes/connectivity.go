@@ -0,0 +1,386 @@
+package main
+
+import (
+	"container/heap"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// stronglyConnectedComponents returns the SCCs of g using Tarjan's
+// algorithm; stations inside a component and components of equal size are
+// sorted by name, larger components first.
+func stronglyConnectedComponents(g *StationGraph) [][]string {
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(v string)
+	visit = func(v string) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, e := range g.adjList[v] {
+			if _, seen := index[e.To]; !seen {
+				visit(e.To)
+				low[v] = min(low[v], low[e.To])
+			} else if onStack[e.To] {
+				low[v] = min(low[v], index[e.To])
+			}
+		}
+		if low[v] == index[v] {
+			var component []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+	for _, v := range g.stations() {
+		if _, seen := index[v]; !seen {
+			visit(v)
+		}
+	}
+	sort.SliceStable(components, func(i, j int) bool {
+		if len(components[i]) != len(components[j]) {
+			return len(components[i]) > len(components[j])
+		}
+		return components[i][0] < components[j][0]
+	})
+	return components
+}
+
+// unreachableFrom lists the stations that cannot be reached from start by
+// following routes in their direction.
+func unreachableFrom(g *StationGraph, start string) ([]string, error) {
+	if !g.hasStation(start) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStation, start)
+	}
+	seen := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, e := range g.adjList[v] {
+			if !seen[e.To] {
+				seen[e.To] = true
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	var result []string
+	for _, v := range g.stations() {
+		if !seen[v] {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// Bridge is a route whose removal disconnects the network when routes are
+// treated as two-way links.
+type Bridge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// articulationPoints finds the cut stations and bridges of the undirected
+// network formed by the routes. Parallel routes between the same stations
+// are never bridges.
+func articulationPoints(routes []Route) ([]string, []Bridge) {
+	type link struct {
+		to string
+		id int
+	}
+	neighbours := make(map[string][]link)
+	for i, r := range routes {
+		if r.StartStation == r.EndStation {
+			continue
+		}
+		neighbours[r.StartStation] = append(neighbours[r.StartStation], link{r.EndStation, i})
+		neighbours[r.EndStation] = append(neighbours[r.EndStation], link{r.StartStation, i})
+	}
+	stations := make([]string, 0, len(neighbours))
+	for v := range neighbours {
+		stations = append(stations, v)
+	}
+	sort.Strings(stations)
+
+	disc := make(map[string]int)
+	low := make(map[string]int)
+	cut := make(map[string]bool)
+	bridges := []Bridge{}
+
+	var visit func(v string, parentEdge int)
+	visit = func(v string, parentEdge int) {
+		disc[v] = len(disc) + 1
+		low[v] = disc[v]
+		children := 0
+		for _, l := range neighbours[v] {
+			if l.id == parentEdge {
+				continue
+			}
+			if disc[l.to] == 0 {
+				children++
+				visit(l.to, l.id)
+				low[v] = min(low[v], low[l.to])
+				if parentEdge >= 0 && low[l.to] >= disc[v] {
+					cut[v] = true
+				}
+				if low[l.to] > disc[v] {
+					bridges = append(bridges, Bridge{From: routes[l.id].StartStation, To: routes[l.id].EndStation})
+				}
+			} else {
+				low[v] = min(low[v], disc[l.to])
+			}
+		}
+		if parentEdge < 0 && children > 1 {
+			cut[v] = true
+		}
+	}
+	for _, v := range stations {
+		if disc[v] == 0 {
+			visit(v, -1)
+		}
+	}
+
+	points := make([]string, 0, len(cut))
+	for v := range cut {
+		points = append(points, v)
+	}
+	sort.Strings(points)
+	sort.Slice(bridges, func(i, j int) bool {
+		if bridges[i].From != bridges[j].From {
+			return bridges[i].From < bridges[j].From
+		}
+		return bridges[i].To < bridges[j].To
+	})
+	return points, bridges
+}
+
+// StationCentrality ranks a station's importance in the network. Degree is
+// the number of distinct neighbours divided by n-1; Betweenness is the
+// normalized share of shortest paths between other stations passing
+// through it.
+type StationCentrality struct {
+	Station     string  `json:"station"`
+	InDegree    int     `json:"in_degree"`
+	OutDegree   int     `json:"out_degree"`
+	Degree      float64 `json:"degree"`
+	Betweenness float64 `json:"betweenness"`
+}
+
+// betweenness computes betweenness centrality with Brandes' algorithm,
+// using Dijkstra so any non-negative weight can be used.
+func betweenness(g *StationGraph) map[string]float64 {
+	stations := g.stations()
+	result := make(map[string]float64, len(stations))
+	for _, s := range stations {
+		var order []string
+		preds := make(map[string][]string)
+		sigma := map[string]float64{s: 1}
+		dist := map[string]float64{s: 0}
+		done := make(map[string]bool)
+		queue := &priorityQueue{{s, 0}}
+		for queue.Len() > 0 {
+			item := heap.Pop(queue).(queueItem)
+			v := item.station
+			if done[v] {
+				continue
+			}
+			done[v] = true
+			order = append(order, v)
+			for _, e := range g.adjList[v] {
+				d := dist[v] + e.Weight
+				old, seen := dist[e.To]
+				switch {
+				case !seen || d < old:
+					dist[e.To] = d
+					sigma[e.To] = sigma[v]
+					preds[e.To] = []string{v}
+					heap.Push(queue, queueItem{e.To, d})
+				case d == old && !done[e.To]:
+					sigma[e.To] += sigma[v]
+					preds[e.To] = append(preds[e.To], v)
+				}
+			}
+		}
+
+		delta := make(map[string]float64)
+		for i := len(order) - 1; i >= 0; i-- {
+			w := order[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				result[w] += delta[w]
+			}
+		}
+	}
+	if n := len(stations); n > 2 {
+		for v := range result {
+			result[v] /= float64((n - 1) * (n - 2))
+		}
+	}
+	return result
+}
+
+func centrality(g *StationGraph) []StationCentrality {
+	stations := g.stations()
+	in := make(map[string]map[string]bool)
+	out := make(map[string]map[string]bool)
+	for _, v := range stations {
+		in[v], out[v] = make(map[string]bool), make(map[string]bool)
+	}
+	for v, edges := range g.adjList {
+		for _, e := range edges {
+			if e.To != v {
+				out[v][e.To] = true
+				in[e.To][v] = true
+			}
+		}
+	}
+
+	between := betweenness(g)
+	result := make([]StationCentrality, len(stations))
+	for i, v := range stations {
+		neighbours := len(out[v])
+		for u := range in[v] {
+			if !out[v][u] {
+				neighbours++
+			}
+		}
+		c := StationCentrality{Station: v, InDegree: len(in[v]), OutDegree: len(out[v]), Betweenness: between[v]}
+		if len(stations) > 1 {
+			c.Degree = float64(neighbours) / float64(len(stations)-1)
+		}
+		result[i] = c
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Betweenness != result[j].Betweenness {
+			return result[i].Betweenness > result[j].Betweenness
+		}
+		return result[i].Degree > result[j].Degree
+	})
+	return result
+}
+
+type ConnectivityReport struct {
+	Stations           int                 `json:"stations"`
+	Routes             int                 `json:"routes"`
+	Components         [][]string          `json:"strongly_connected_components"`
+	From               string              `json:"from,omitempty"`
+	Unreachable        []string            `json:"unreachable,omitempty"`
+	ArticulationPoints []string            `json:"articulation_points"`
+	Bridges            []Bridge            `json:"bridges"`
+	Centrality         []StationCentrality `json:"centrality"`
+}
+
+func buildConnectivityReport(routes []Route, from string, weight WeightFunc) (ConnectivityReport, error) {
+	g := buildStationGraph(routes, weight, false)
+	report := ConnectivityReport{
+		Stations:   len(g.adjList),
+		Routes:     len(routes),
+		Components: stronglyConnectedComponents(g),
+		From:       from,
+		Centrality: centrality(g),
+	}
+	if from != "" {
+		unreachable, err := unreachableFrom(g, from)
+		if err != nil {
+			return ConnectivityReport{}, err
+		}
+		report.Unreachable = unreachable
+	}
+	report.ArticulationPoints, report.Bridges = articulationPoints(routes)
+	return report, nil
+}
+
+func writeConnectivityReport(w io.Writer, report ConnectivityReport, top int) error {
+	fmt.Fprintf(w, "Stations: %d, routes: %d\n", report.Stations, report.Routes)
+	fmt.Fprintf(w, "\nStrongly connected components: %d\n", len(report.Components))
+	for i, c := range report.Components {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, strings.Join(c, ", "))
+	}
+	if report.From != "" {
+		fmt.Fprintf(w, "\nUnreachable from %s: %d\n", report.From, len(report.Unreachable))
+		for _, v := range report.Unreachable {
+			fmt.Fprintf(w, "  %s\n", v)
+		}
+	}
+	fmt.Fprintf(w, "\nArticulation points: %s\n", joinOrNone(report.ArticulationPoints))
+	fmt.Fprintln(w, "Bridges:")
+	if len(report.Bridges) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	for _, b := range report.Bridges {
+		fmt.Fprintf(w, "  %s - %s\n", b.From, b.To)
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATION\tIN\tOUT\tDEGREE\tBETWEENNESS")
+	for i, c := range report.Centrality {
+		if top > 0 && i == top {
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.3f\t%.3f\n", c.Station, c.InDegree, c.OutDegree, c.Degree, c.Betweenness)
+	}
+	return tw.Flush()
+}
+
+func joinOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+func runConnectivity(args []string) {
+	fs := flag.NewFlagSet("connectivity", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	from := fs.String("from", "", "also list stations unreachable from this station")
+	weightName := fs.String("weight", "hops", "shortest path weight for betweenness: hops, distance or stops")
+	top := fs.Int("top", 0, "only print the top N stations by centrality in text output")
+	fs.Parse(args)
+
+	weight, err := weightFuncByName(*weightName)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	report, err := buildConnectivityReport(routes, *from, weight)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	switch *opts.format {
+	case "json", "ndjson":
+		err = writeJSON(os.Stdout, report)
+	default:
+		err = writeConnectivityReport(os.Stdout, report, *top)
+	}
+	if err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
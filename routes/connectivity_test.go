@@ -0,0 +1,163 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func hops(Route) float64 { return 1 }
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []Route
+		want   [][]string
+	}{
+		{
+			// A -> B -> C -> A is a cycle with a parallel B -> C route;
+			// D <-> E with a self-loop on D hangs off it and F only leads in.
+			name: "cycles",
+			routes: []Route{
+				{"A", "B", 1, 1}, {"B", "C", 1, 1}, {"B", "C", 2, 2}, {"C", "A", 1, 1},
+				{"C", "D", 1, 1}, {"D", "E", 1, 1}, {"E", "D", 1, 1}, {"D", "D", 1, 1},
+				{"F", "A", 1, 1},
+			},
+			want: [][]string{{"A", "B", "C"}, {"D", "E"}, {"F"}},
+		},
+		{
+			name:   "chain",
+			routes: []Route{{"C", "B", 1, 1}, {"B", "A", 1, 1}},
+			want:   [][]string{{"A"}, {"B"}, {"C"}},
+		},
+		{
+			name:   "self-loop only",
+			routes: []Route{{"A", "A", 1, 1}},
+			want:   [][]string{{"A"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stronglyConnectedComponents(buildStationGraph(tt.routes, hops, false))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("components = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnreachableFrom(t *testing.T) {
+	g := buildStationGraph([]Route{{"A", "B", 1, 1}, {"C", "A", 1, 1}, {"B", "B", 1, 1}}, hops, false)
+	got, err := unreachableFrom(g, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{"C"}) {
+		t.Errorf("unreachable = %v, want [C]", got)
+	}
+	if _, err := unreachableFrom(g, "Z"); err == nil {
+		t.Error("unreachableFrom accepted an unknown station")
+	}
+}
+
+func TestArticulationPoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		routes     []Route
+		wantPoints []string
+		wantBridge []Bridge
+	}{
+		{
+			// G - B - A with a self-loop on A, B - C, C = D doubled, and the
+			// triangle D - E - F. The doubled C = D link is not a bridge
+			// but C and D still separate the network.
+			name: "mixed",
+			routes: []Route{
+				{"A", "B", 1, 1}, {"A", "A", 1, 1}, {"G", "B", 1, 1}, {"B", "C", 1, 1},
+				{"C", "D", 1, 1}, {"D", "C", 1, 1},
+				{"D", "E", 1, 1}, {"E", "F", 1, 1}, {"F", "D", 1, 1},
+			},
+			wantPoints: []string{"B", "C", "D"},
+			wantBridge: []Bridge{{"A", "B"}, {"B", "C"}, {"G", "B"}},
+		},
+		{
+			name:       "cycle",
+			routes:     []Route{{"A", "B", 1, 1}, {"B", "C", 1, 1}, {"C", "A", 1, 1}},
+			wantPoints: []string{},
+			wantBridge: []Bridge{},
+		},
+		{
+			name:       "parallel routes",
+			routes:     []Route{{"A", "B", 1, 1}, {"A", "B", 2, 2}},
+			wantPoints: []string{},
+			wantBridge: []Bridge{},
+		},
+		{
+			name:       "two components",
+			routes:     []Route{{"A", "B", 1, 1}, {"C", "D", 1, 1}, {"D", "E", 1, 1}},
+			wantPoints: []string{"D"},
+			wantBridge: []Bridge{{"A", "B"}, {"C", "D"}, {"D", "E"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, bridges := articulationPoints(tt.routes)
+			if !reflect.DeepEqual(points, tt.wantPoints) {
+				t.Errorf("articulation points = %v, want %v", points, tt.wantPoints)
+			}
+			if !reflect.DeepEqual(bridges, tt.wantBridge) {
+				t.Errorf("bridges = %v, want %v", bridges, tt.wantBridge)
+			}
+		})
+	}
+}
+
+func TestBetweenness(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []Route
+		weight WeightFunc
+		want   map[string]float64
+	}{
+		{
+			// B is on both shortest paths between A and C out of 3·2 pairs.
+			name:   "two-way line",
+			routes: []Route{{"A", "B", 1, 1}, {"B", "A", 1, 1}, {"B", "C", 1, 1}, {"C", "B", 1, 1}},
+			weight: hops,
+			want:   map[string]float64{"B": 1},
+		},
+		{
+			// A -> D has two shortest paths, one through each of B and C,
+			// out of 4·3 ordered pairs; the self-loop on D changes nothing.
+			name:   "diamond",
+			routes: []Route{{"A", "B", 1, 1}, {"A", "C", 1, 1}, {"B", "D", 1, 1}, {"C", "D", 1, 1}, {"D", "D", 1, 1}},
+			weight: hops,
+			want:   map[string]float64{"B": 0.5 / 6, "C": 0.5 / 6},
+		},
+		{
+			// A second A -> B route doubles the shortest paths through B.
+			name:   "parallel routes",
+			routes: []Route{{"A", "B", 1, 1}, {"A", "B", 2, 2}, {"A", "C", 1, 1}, {"B", "D", 1, 1}, {"C", "D", 1, 1}},
+			weight: hops,
+			want:   map[string]float64{"B": 2.0 / 3 / 6, "C": 1.0 / 3 / 6},
+		},
+		{
+			// By distance the longer parallel A -> B route is never shortest
+			// and A -> C -> D (1 + 1) beats A -> B -> D (1 + 2).
+			name:   "parallel routes by distance",
+			routes: []Route{{"A", "B", 1, 1}, {"A", "B", 1, 5}, {"A", "C", 1, 1}, {"B", "D", 1, 2}, {"C", "D", 1, 1}},
+			weight: weightByDistance,
+			want:   map[string]float64{"C": 1.0 / 6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := betweenness(buildStationGraph(tt.routes, tt.weight, false))
+			for _, v := range []string{"A", "B", "C", "D"} {
+				if math.Abs(got[v]-tt.want[v]) > 1e-12 {
+					t.Errorf("betweenness[%s] = %g, want %g", v, got[v], tt.want[v])
+				}
+			}
+		})
+	}
+}
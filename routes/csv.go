@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	colStart = iota
+	colEnd
+	colStops
+	colDistance
+	numColumns
+)
+
+var columnNames = [numColumns]string{"start", "end", "stops", "distance"}
+
+// columnAliases maps normalized header names to columns.
+var columnAliases = map[string]int{
+	"start": colStart, "startstation": colStart, "from": colStart, "origin": colStart,
+	"end": colEnd, "endstation": colEnd, "to": colEnd, "destination": colEnd,
+	"stops": colStops, "numstops": colStops, "stopcount": colStops,
+	"distance": colDistance, "distancekm": colDistance, "km": colDistance,
+}
+
+// ReadOptions control how routes files are parsed. A zero Delimiter is
+// detected from the first line. In lenient mode bad rows are skipped and
+// reported instead of aborting the read.
+type ReadOptions struct {
+	Delimiter rune
+	Lenient   bool
+}
+
+// RowError describes a single invalid row of a routes file.
+type RowError struct {
+	Line   int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	msg := fmt.Sprintf("line %d", e.Line)
+	if e.Column != "" {
+		msg += ", column " + e.Column
+	}
+	if e.Value != "" {
+		msg += fmt.Sprintf(" (%q)", e.Value)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ErrMissingField  = errors.New("missing field")
+	ErrInvalidNumber = errors.New("invalid number")
+	ErrNegative      = errors.New("value must not be negative")
+	ErrEmptyStation  = errors.New("station name is empty")
+)
+
+func normalizeHeader(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("_", "", " ", "", "-", "", "(", "", ")", "").Replace(name)
+}
+
+// detectHeader returns the column positions named by record, or ok=false
+// when record does not look like a header.
+func detectHeader(record []string) (positions [numColumns]int, ok bool) {
+	for i := range positions {
+		positions[i] = -1
+	}
+	for i, field := range record {
+		if col, known := columnAliases[normalizeHeader(field)]; known && positions[col] < 0 {
+			positions[col] = i
+		}
+	}
+	for _, p := range positions {
+		if p < 0 {
+			return positions, false
+		}
+	}
+	return positions, true
+}
+
+func sniffDelimiter(line string) rune {
+	best, bestCount := ',', 0
+	for _, d := range []rune{',', ';', '\t', '|'} {
+		if n := strings.Count(line, string(d)); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
+
+func parseDelimiter(name string) (rune, error) {
+	switch name {
+	case "", "auto":
+		return 0, nil
+	case ",", "comma":
+		return ',', nil
+	case ";", "semicolon":
+		return ';', nil
+	case "\\t", "tab":
+		return '\t', nil
+	case "|", "pipe":
+		return '|', nil
+	}
+	return 0, fmt.Errorf("unknown delimiter %q", name)
+}
+
+// parseRoute validates one record using the given column positions.
+func parseRoute(record []string, positions [numColumns]int, line int) (Route, *RowError) {
+	field := func(col int) (string, *RowError) {
+		if positions[col] >= len(record) {
+			return "", &RowError{Line: line, Column: columnNames[col], Err: ErrMissingField}
+		}
+		return strings.TrimSpace(record[positions[col]]), nil
+	}
+
+	var values [numColumns]string
+	for col := range values {
+		v, err := field(col)
+		if err != nil {
+			return Route{}, err
+		}
+		values[col] = v
+	}
+
+	for _, col := range []int{colStart, colEnd} {
+		if values[col] == "" {
+			return Route{}, &RowError{Line: line, Column: columnNames[col], Err: ErrEmptyStation}
+		}
+	}
+	numStops, err := strconv.Atoi(values[colStops])
+	if err != nil {
+		return Route{}, &RowError{Line: line, Column: columnNames[colStops], Value: values[colStops], Err: ErrInvalidNumber}
+	}
+	if numStops < 0 {
+		return Route{}, &RowError{Line: line, Column: columnNames[colStops], Value: values[colStops], Err: ErrNegative}
+	}
+	distance, err := strconv.ParseFloat(values[colDistance], 64)
+	if err != nil || math.IsNaN(distance) || math.IsInf(distance, 0) {
+		return Route{}, &RowError{Line: line, Column: columnNames[colDistance], Value: values[colDistance], Err: ErrInvalidNumber}
+	}
+	if distance < 0 {
+		return Route{}, &RowError{Line: line, Column: columnNames[colDistance], Value: values[colDistance], Err: ErrNegative}
+	}
+
+	return Route{
+		StartStation: values[colStart],
+		EndStation:   values[colEnd],
+		NumStops:     numStops,
+		Distance:     distance,
+	}, nil
+}
+
+// streamRoutes parses routes from r and calls fn for each valid route as it
+// is read, so files of any size can be processed in constant memory. A
+// header row with named columns in any order is detected automatically;
+// without one the columns are start, end, stops, distance. In strict mode
+// the first bad row is returned as the error; in lenient mode bad rows are
+// returned separately. An error from fn stops the read and is returned.
+func streamRoutes(r io.Reader, opts ReadOptions, fn func(Route) error) ([]*RowError, error) {
+	buffered := bufio.NewReader(r)
+	delim := opts.Delimiter
+	if delim == 0 {
+		peek, _ := buffered.Peek(4096)
+		firstLine, _, _ := strings.Cut(string(peek), "\n")
+		delim = sniffDelimiter(firstLine)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = delim
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	positions := [numColumns]int{colStart, colEnd, colStops, colDistance}
+	var rowErrors []*RowError
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			rowErr := &RowError{Line: parseErr.Line, Err: parseErr.Err}
+			if !opts.Lenient {
+				return nil, rowErr
+			}
+			rowErrors = append(rowErrors, rowErr)
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			first = false
+			if header, ok := detectHeader(record); ok {
+				positions = header
+				continue
+			}
+		}
+
+		route, rowErr := parseRoute(record, positions, line)
+		if rowErr != nil {
+			if !opts.Lenient {
+				return nil, rowErr
+			}
+			rowErrors = append(rowErrors, rowErr)
+			continue
+		}
+		if err := fn(route); err != nil {
+			return rowErrors, err
+		}
+	}
+	return rowErrors, nil
+}
+
+// readRoutes reads all routes of r into memory; see streamRoutes.
+func readRoutes(r io.Reader, opts ReadOptions) ([]Route, []*RowError, error) {
+	var routes []Route
+	rowErrors, err := streamRoutes(r, opts, func(route Route) error {
+		routes = append(routes, route)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return routes, rowErrors, nil
+}
+
+func readRoutesFromFileWithOptions(filename string, opts ReadOptions) ([]Route, []*RowError, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	routes, rowErrors, err := readRoutes(file, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return routes, rowErrors, nil
+}
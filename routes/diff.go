@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// routeKey identifies a route across two versions of a dataset. When a
+// dataset has several routes between the same stations they are matched in
+// file order and told apart by Occurrence.
+type routeKey struct {
+	Start      string
+	End        string
+	Occurrence int
+}
+
+func (k routeKey) String() string {
+	s := k.Start + " -> " + k.End
+	if k.Occurrence > 1 {
+		s += " #" + strconv.Itoa(k.Occurrence)
+	}
+	return s
+}
+
+// FieldChange is a single modified field of a route.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+type RouteChange struct {
+	Key     string        `json:"key"`
+	Old     Route         `json:"old"`
+	New     Route         `json:"new"`
+	Changes []FieldChange `json:"changes"`
+}
+
+type RouteDiff struct {
+	Added     []Route       `json:"added"`
+	Removed   []Route       `json:"removed"`
+	Modified  []RouteChange `json:"modified"`
+	Unchanged int           `json:"unchanged"`
+}
+
+func keyRoutes(routes []Route) (map[routeKey]Route, []routeKey) {
+	byKey := make(map[routeKey]Route, len(routes))
+	keys := make([]routeKey, 0, len(routes))
+	seen := make(map[[2]string]int)
+	for _, r := range routes {
+		pair := [2]string{r.StartStation, r.EndStation}
+		seen[pair]++
+		k := routeKey{r.StartStation, r.EndStation, seen[pair]}
+		byKey[k] = r
+		keys = append(keys, k)
+	}
+	return byKey, keys
+}
+
+// diffRoutes compares two datasets by (start, end). Distances closer than
+// tolerance count as equal.
+func diffRoutes(oldRoutes, newRoutes []Route, tolerance float64) RouteDiff {
+	oldByKey, oldKeys := keyRoutes(oldRoutes)
+	newByKey, newKeys := keyRoutes(newRoutes)
+	d := RouteDiff{Added: []Route{}, Removed: []Route{}, Modified: []RouteChange{}}
+
+	for _, k := range oldKeys {
+		o := oldByKey[k]
+		n, ok := newByKey[k]
+		if !ok {
+			d.Removed = append(d.Removed, o)
+			continue
+		}
+		changes := routeChanges(o, n, tolerance)
+		if len(changes) == 0 {
+			d.Unchanged++
+			continue
+		}
+		d.Modified = append(d.Modified, RouteChange{Key: k.String(), Old: o, New: n, Changes: changes})
+	}
+	for _, k := range newKeys {
+		if _, ok := oldByKey[k]; !ok {
+			d.Added = append(d.Added, newByKey[k])
+		}
+	}
+	return d
+}
+
+// routeChanges lists the fields that differ between two versions of a
+// route, named as in the route's JSON form.
+func routeChanges(o, n Route, tolerance float64) []FieldChange {
+	var changes []FieldChange
+	if o.NumStops != n.NumStops {
+		changes = append(changes, FieldChange{"stops", o.NumStops, n.NumStops})
+	}
+	if math.Abs(o.Distance-n.Distance) > tolerance {
+		changes = append(changes, FieldChange{"distance", o.Distance, n.Distance})
+	}
+	return changes
+}
+
+func (d RouteDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+func describeRoute(r Route) string {
+	return fmt.Sprintf("%s -> %s (%d stops, %s km)", r.StartStation, r.EndStation, r.NumStops, formatDistance(r.Distance))
+}
+
+func formatValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return formatDistance(f)
+	}
+	return fmt.Sprint(v)
+}
+
+func writeDiffText(w io.Writer, d RouteDiff) error {
+	for _, r := range d.Removed {
+		fmt.Fprintln(w, "-", describeRoute(r))
+	}
+	for _, r := range d.Added {
+		fmt.Fprintln(w, "+", describeRoute(r))
+	}
+	for _, c := range d.Modified {
+		var parts []string
+		for _, fc := range c.Changes {
+			parts = append(parts, fmt.Sprintf("%s %s -> %s", fc.Field, formatValue(fc.Old), formatValue(fc.New)))
+		}
+		fmt.Fprintf(w, "~ %s: %s\n", c.Key, strings.Join(parts, ", "))
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d modified, %d unchanged\n",
+		len(d.Added), len(d.Removed), len(d.Modified), d.Unchanged)
+	return err
+}
+
+// PatchOp is one RFC 6902 JSON Patch operation.
+type PatchOp struct {
+	Op    string `json:"op"`
+	From  string `json:"from,omitempty"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
+// jsonPatch returns a JSON Patch that turns the array written by
+// "-format json" for oldRoutes into the one for newRoutes. Removed routes
+// go first, last index first, then field changes of the remaining routes,
+// then moves and adds that put them in the order of newRoutes.
+func jsonPatch(oldRoutes, newRoutes []Route, tolerance float64) []PatchOp {
+	patch := []PatchOp{}
+	_, oldKeys := keyRoutes(oldRoutes)
+	newByKey, newKeys := keyRoutes(newRoutes)
+	for i := len(oldKeys) - 1; i >= 0; i-- {
+		if _, ok := newByKey[oldKeys[i]]; !ok {
+			patch = append(patch, PatchOp{Op: "remove", Path: "/" + strconv.Itoa(i)})
+		}
+	}
+
+	var current []routeKey
+	for i, k := range oldKeys {
+		n, ok := newByKey[k]
+		if !ok {
+			continue
+		}
+		path := "/" + strconv.Itoa(len(current))
+		for _, fc := range routeChanges(oldRoutes[i], n, tolerance) {
+			patch = append(patch, PatchOp{Op: "replace", Path: path + "/" + fc.Field, Value: fc.New})
+		}
+		current = append(current, k)
+	}
+
+	for i, k := range newKeys {
+		path := "/" + strconv.Itoa(i)
+		at := slices.Index(current[i:], k)
+		switch {
+		case at < 0:
+			patch = append(patch, PatchOp{Op: "add", Path: path, Value: newRoutes[i]})
+			current = slices.Insert(current, i, k)
+		case at > 0:
+			patch = append(patch, PatchOp{Op: "move", From: "/" + strconv.Itoa(i+at), Path: path})
+			current = slices.Delete(current, i+at, i+at+1)
+			current = slices.Insert(current, i, k)
+		}
+	}
+	return patch
+}
+
+// runDiff exits with 0 when the files match, 1 when they differ and 2 on
+// errors, like diff(1).
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: text, json or patch (RFC 6902 JSON Patch over the -format json array)")
+	delimName := fs.String("delim", "auto", "field delimiter: auto, comma, semicolon, tab or pipe")
+	lenient := fs.Bool("lenient", false, "skip invalid rows instead of failing")
+	tolerance := fs.Float64("tolerance", 0, "ignore distance changes up to this many km")
+	fs.Parse(args)
+
+	fail := func(a ...any) {
+		fmt.Fprintln(os.Stderr, a...)
+		os.Exit(2)
+	}
+	if fs.NArg() != 2 {
+		fail("Usage: go run *.go diff [flags] <old.csv> <new.csv>")
+	}
+	if *format != "text" && *format != "json" && *format != "patch" {
+		fail(fmt.Sprintf("unknown format %q, expected text, json or patch", *format))
+	}
+	delim, err := parseDelimiter(*delimName)
+	if err != nil {
+		fail(err)
+	}
+	opts := ReadOptions{Delimiter: delim, Lenient: *lenient}
+	oldRoutes, err := loadRoutes(fs.Arg(0), opts)
+	if err != nil {
+		fail("Error reading file:", err)
+	}
+	newRoutes, err := loadRoutes(fs.Arg(1), opts)
+	if err != nil {
+		fail("Error reading file:", err)
+	}
+
+	d := diffRoutes(oldRoutes, newRoutes, *tolerance)
+	switch *format {
+	case "json":
+		err = writeJSON(os.Stdout, d)
+	case "patch":
+		err = writeJSON(os.Stdout, jsonPatch(oldRoutes, newRoutes, *tolerance))
+	default:
+		err = writeDiffText(os.Stdout, d)
+	}
+	if err != nil {
+		fail("Error writing output:", err)
+	}
+	if !d.empty() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffRoutes(t *testing.T) {
+	oldRoutes := []Route{
+		{"A", "B", 3, 10},
+		{"A", "B", 4, 12},
+		{"B", "C", 2, 8},
+		{"C", "D", 5, 20},
+	}
+	newRoutes := []Route{
+		{"A", "B", 3, 10.05},
+		{"B", "C", 3, 8},
+		{"D", "E", 1, 2},
+	}
+
+	d := diffRoutes(oldRoutes, newRoutes, 0)
+	if want := []Route{{"D", "E", 1, 2}}; !reflect.DeepEqual(d.Added, want) {
+		t.Errorf("added = %v, want %v", d.Added, want)
+	}
+	if want := []Route{{"A", "B", 4, 12}, {"C", "D", 5, 20}}; !reflect.DeepEqual(d.Removed, want) {
+		t.Errorf("removed = %v, want the second A -> B and C -> D", d.Removed)
+	}
+	if len(d.Modified) != 2 || d.Modified[0].Key != "A -> B" || d.Modified[1].Key != "B -> C" {
+		t.Fatalf("modified = %+v, want A -> B and B -> C", d.Modified)
+	}
+	if c := d.Modified[1].Changes; len(c) != 1 || c[0] != (FieldChange{"stops", 2, 3}) {
+		t.Errorf("B -> C changes = %v, want stops 2 -> 3", c)
+	}
+
+	d = diffRoutes(oldRoutes, newRoutes, 0.1)
+	if len(d.Modified) != 1 || d.Unchanged != 1 {
+		t.Errorf("with tolerance 0.1: %d modified, %d unchanged, want 1 and 1", len(d.Modified), d.Unchanged)
+	}
+	if d = diffRoutes(oldRoutes, oldRoutes, 0); !d.empty() || d.Unchanged != len(oldRoutes) {
+		t.Errorf("diff of a dataset with itself = %+v", d)
+	}
+}
+
+func TestDiffRoutesDuplicates(t *testing.T) {
+	oldRoutes := []Route{{"A", "B", 1, 1}, {"A", "B", 2, 2}}
+	newRoutes := []Route{{"A", "B", 1, 1}, {"A", "B", 2, 2}, {"A", "B", 3, 3}}
+	d := diffRoutes(oldRoutes, newRoutes, 0)
+	if len(d.Added) != 1 || d.Added[0].NumStops != 3 || len(d.Removed) != 0 || d.Unchanged != 2 {
+		t.Errorf("diff = %+v, want the third A -> B added", d)
+	}
+
+	d = diffRoutes(newRoutes, oldRoutes, 0)
+	if len(d.Removed) != 1 || d.Removed[0].NumStops != 3 {
+		t.Errorf("diff = %+v, want the third A -> B removed", d)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new []Route
+	}{
+		{"identical", []Route{{"A", "B", 1, 1}}, []Route{{"A", "B", 1, 1}}},
+		{"empty to routes", nil, []Route{{"A", "B", 1, 1}, {"B/C", "~D", 2, 2}}},
+		{"routes to empty", []Route{{"A", "B", 1, 1}, {"B", "C", 2, 2}}, nil},
+		{
+			"add, remove and modify",
+			[]Route{{"A", "B", 1, 1}, {"B", "C", 2, 2}, {"C", "D", 3, 3}},
+			[]Route{{"A", "B", 1, 1.5}, {"C", "D", 4, 3}, {"D", "E", 5, 5}},
+		},
+		{
+			"reordered",
+			[]Route{{"A", "B", 1, 1}, {"B", "C", 2, 2}, {"C", "D", 3, 3}},
+			[]Route{{"C", "D", 3, 3}, {"X", "Y", 9, 9}, {"A", "B", 1, 1}, {"B", "C", 2, 7}},
+		},
+		{
+			"duplicates",
+			[]Route{{"A", "B", 1, 1}, {"A", "B", 2, 2}, {"A", "B", 3, 3}, {"B", "C", 1, 1}},
+			[]Route{{"B", "C", 1, 1}, {"A", "B", 1, 1}, {"A", "B", 5, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := jsonPatch(tt.old, tt.new, 0)
+			got := applyPatch(t, routesJSON(t, tt.old), patch)
+			if want := routesJSON(t, tt.new); !reflect.DeepEqual(got, want) {
+				t.Errorf("patched = %v\nwant %v\npatch %+v", got, want, patch)
+			}
+		})
+	}
+}
+
+func TestJSONPatchTolerance(t *testing.T) {
+	oldRoutes := []Route{{"A", "B", 1, 10}}
+	newRoutes := []Route{{"A", "B", 1, 10.01}}
+	if patch := jsonPatch(oldRoutes, newRoutes, 0.1); len(patch) != 0 {
+		t.Errorf("patch = %+v, want none within the tolerance", patch)
+	}
+	if patch := jsonPatch(oldRoutes, newRoutes, 0); len(patch) != 1 || patch[0].Path != "/0/distance" {
+		t.Errorf("patch = %+v, want a replace of /0/distance", patch)
+	}
+}
+
+// routesJSON decodes the array written by writeRoutes in json format.
+func routesJSON(t *testing.T, routes []Route) []any {
+	t.Helper()
+	var b bytes.Buffer
+	if err := writeRoutes(&b, routes, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var doc []any
+	if err := json.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// applyPatch applies the subset of RFC 6902 that jsonPatch emits to an
+// array of route objects.
+func applyPatch(t *testing.T, doc []any, patch []PatchOp) []any {
+	t.Helper()
+	data, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []map[string]any
+	if err := json.Unmarshal(data, &ops); err != nil {
+		t.Fatal(err)
+	}
+
+	index := func(token string, n int) int {
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i > n {
+			t.Fatalf("bad array index %q for length %d", token, n)
+		}
+		return i
+	}
+	for _, op := range ops {
+		path := strings.Split(strings.TrimPrefix(op["path"].(string), "/"), "/")
+		switch op["op"] {
+		case "add":
+			i := index(path[0], len(doc))
+			doc = append(doc[:i], append([]any{op["value"]}, doc[i:]...)...)
+		case "remove":
+			i := index(path[0], len(doc)-1)
+			doc = append(doc[:i], doc[i+1:]...)
+		case "replace":
+			i := index(path[0], len(doc)-1)
+			route := doc[i].(map[string]any)
+			if _, ok := route[path[1]]; !ok {
+				t.Fatalf("replace of missing member %q", op["path"])
+			}
+			route[path[1]] = op["value"]
+		case "move":
+			from := index(strings.TrimPrefix(op["from"].(string), "/"), len(doc)-1)
+			value := doc[from]
+			doc = append(doc[:from], doc[from+1:]...)
+			i := index(path[0], len(doc))
+			doc = append(doc[:i], append([]any{value}, doc[i:]...)...)
+		default:
+			t.Fatalf("unexpected op %v", op["op"])
+		}
+	}
+	if doc == nil {
+		doc = []any{}
+	}
+	return doc
+}
+
+func ExampleRouteDiff() {
+	d := diffRoutes(
+		[]Route{{"A", "B", 3, 10}, {"B", "C", 2, 8}},
+		[]Route{{"A", "B", 4, 10}, {"C", "D", 1, 5}},
+		0,
+	)
+	writeDiffText(os.Stdout, d)
+	// Output:
+	// - B -> C (2 stops, 8 km)
+	// + C -> D (1 stops, 5 km)
+	// ~ A -> B: stops 3 -> 4
+	// 1 added, 1 removed, 1 modified, 0 unchanged
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// networkStations lists every station used by routes in name order.
+func networkStations(routes []Route) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range routes {
+		for _, name := range []string{r.StartStation, r.EndStation} {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dotQuote quotes s as a DOT string ID, where only " and \ are escaped.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// writeDOT writes a Graphviz digraph. Stations with coordinates get a
+// pinned pos attribute so neato -n lays them out geographically.
+func writeDOT(w io.Writer, routes []Route, coords map[string]Station) error {
+	fmt.Fprintln(w, "digraph routes {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box, style=rounded];")
+	for _, name := range networkStations(routes) {
+		if s, ok := coords[name]; ok {
+			fmt.Fprintf(w, "  %s [pos=\"%g,%g!\"];\n", dotQuote(name), s.Lon, s.Lat)
+		} else {
+			fmt.Fprintf(w, "  %s;\n", dotQuote(name))
+		}
+	}
+	for _, r := range routes {
+		label := fmt.Sprintf("%s km, %d stops", formatDistance(r.Distance), r.NumStops)
+		fmt.Fprintf(w, "  %s -> %s [label=%s];\n", dotQuote(r.StartStation), dotQuote(r.EndStation), dotQuote(label))
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// writeGraphML writes the network in the GraphML format read by Gephi and
+// yEd. Node ids are the station names; coordinates are added as lat/lon
+// attributes when known.
+func writeGraphML(w io.Writer, routes []Route, coords map[string]Station) error {
+	doc := graphMLDocument{XMLNS: "http://graphml.graphdrawing.org/xmlns"}
+	doc.Keys = []graphMLKey{
+		{ID: "label", For: "node", Name: "label", Type: "string"},
+		{ID: "lat", For: "node", Name: "lat", Type: "double"},
+		{ID: "lon", For: "node", Name: "lon", Type: "double"},
+		{ID: "distance", For: "edge", Name: "distance", Type: "double"},
+		{ID: "stops", For: "edge", Name: "stops", Type: "int"},
+	}
+	doc.Graph.ID = "routes"
+	doc.Graph.EdgeDefault = "directed"
+	for _, name := range networkStations(routes) {
+		node := graphMLNode{ID: name, Data: []graphMLData{{"label", name}}}
+		if s, ok := coords[name]; ok {
+			node.Data = append(node.Data,
+				graphMLData{"lat", strconv.FormatFloat(s.Lat, 'f', -1, 64)},
+				graphMLData{"lon", strconv.FormatFloat(s.Lon, 'f', -1, 64)})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for i, r := range routes {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			ID:     "e" + strconv.Itoa(i),
+			Source: r.StartStation,
+			Target: r.EndStation,
+			Data: []graphMLData{
+				{"distance", formatDistance(r.Distance)},
+				{"stops", strconv.Itoa(r.NumStops)},
+			},
+		})
+	}
+
+	io.WriteString(w, xml.Header)
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// writeGeoJSON writes a FeatureCollection with a Point per station and a
+// LineString per route. Routes with a station missing from coords are left
+// out and returned so the caller can report them.
+func writeGeoJSON(w io.Writer, routes []Route, coords map[string]Station) ([]Route, error) {
+	collection := geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	for _, name := range networkStations(routes) {
+		s, ok := coords[name]
+		if !ok {
+			continue
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{"Point", []float64{s.Lon, s.Lat}},
+			Properties: map[string]any{"name": name},
+		})
+	}
+	var skipped []Route
+	for _, r := range routes {
+		a, aOK := coords[r.StartStation]
+		b, bOK := coords[r.EndStation]
+		if !aOK || !bOK {
+			skipped = append(skipped, r)
+			continue
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{"LineString", [][]float64{{a.Lon, a.Lat}, {b.Lon, b.Lat}}},
+			Properties: map[string]any{
+				"start":    r.StartStation,
+				"end":      r.EndStation,
+				"stops":    r.NumStops,
+				"distance": r.Distance,
+			},
+		})
+	}
+	return skipped, writeJSON(w, collection)
+}
+
+var exportFormats = []string{"dot", "graphml", "geojson"}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	to := fs.String("to", "dot", "export format: "+strings.Join(exportFormats, ", "))
+	stationsFile := fs.String("stations", "", "stations file with coordinates (required for geojson)")
+	output := fs.String("o", "", "output file (stdout by default)")
+	fs.Parse(args)
+
+	if !slices.Contains(exportFormats, *to) {
+		fmt.Printf("unknown export format %q, expected one of %v\n", *to, exportFormats)
+		return
+	}
+	if *to == "geojson" && *stationsFile == "" {
+		fmt.Println("geojson export needs -stations")
+		return
+	}
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	coords := make(map[string]Station)
+	if *stationsFile != "" {
+		stations, err := readStationsFromFile(*stationsFile)
+		if err != nil {
+			fmt.Println("Error reading file:", err)
+			return
+		}
+		coords = stationsByName(stations)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Println("Error writing output:", err)
+			return
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *to {
+	case "dot":
+		err = writeDOT(w, routes, coords)
+	case "graphml":
+		err = writeGraphML(w, routes, coords)
+	case "geojson":
+		var skipped []Route
+		skipped, err = writeGeoJSON(w, routes, coords)
+		for _, r := range skipped {
+			fmt.Fprintf(os.Stderr, "skipped %s -> %s: station has no coordinates\n", r.StartStation, r.EndStation)
+		}
+	}
+	if err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var exportTestRoutes = []Route{
+	{StartStation: "Station A", EndStation: `Quay "North"`, NumStops: 3, Distance: 4.5},
+	{StartStation: `Quay "North"`, EndStation: `Depot C:\Yard`, NumStops: 2, Distance: 1.25},
+	{StartStation: "Station A", EndStation: "Station B", NumStops: 5, Distance: 10},
+}
+
+var exportTestCoords = map[string]Station{
+	"Station A":    {Name: "Station A", Lat: 50.45, Lon: 30.52},
+	`Quay "North"`: {Name: `Quay "North"`, Lat: 50.46, Lon: 30.5},
+	"Station B":    {Name: "Station B", Lat: 50.4, Lon: 30.6},
+}
+
+func TestDotQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Station A", `"Station A"`},
+		{`Quay "North"`, `"Quay \"North\""`},
+		{`Depot C:\Yard`, `"Depot C:\\Yard"`},
+		{`\"`, `"\\\""`},
+		{"", `""`},
+	}
+	for _, tt := range tests {
+		if got := dotQuote(tt.in); got != tt.want {
+			t.Errorf("dotQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeDOT(&buf, exportTestRoutes, exportTestCoords); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`"Quay \"North\"" [pos="30.5,50.46!"];`,
+		`"Depot C:\\Yard";`,
+		`"Quay \"North\"" -> "Depot C:\\Yard" [label="1.25 km, 2 stops"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output has no %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteGraphMLRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeGraphML(&buf, exportTestRoutes, exportTestCoords); err != nil {
+		t.Fatal(err)
+	}
+	var doc graphMLDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+	}
+
+	nodes := make(map[string]map[string]string)
+	for _, n := range doc.Graph.Nodes {
+		data := make(map[string]string)
+		for _, d := range n.Data {
+			data[d.Key] = d.Value
+		}
+		nodes[n.ID] = data
+	}
+	wantNodes := map[string]map[string]string{
+		"Station A":     {"label": "Station A", "lat": "50.45", "lon": "30.52"},
+		"Station B":     {"label": "Station B", "lat": "50.4", "lon": "30.6"},
+		`Quay "North"`:  {"label": `Quay "North"`, "lat": "50.46", "lon": "30.5"},
+		`Depot C:\Yard`: {"label": `Depot C:\Yard`},
+	}
+	if !reflect.DeepEqual(nodes, wantNodes) {
+		t.Errorf("nodes = %v, want %v", nodes, wantNodes)
+	}
+
+	if len(doc.Graph.Edges) != len(exportTestRoutes) {
+		t.Fatalf("got %d edges, want %d", len(doc.Graph.Edges), len(exportTestRoutes))
+	}
+	for i, e := range doc.Graph.Edges {
+		r := exportTestRoutes[i]
+		if e.Source != r.StartStation || e.Target != r.EndStation {
+			t.Errorf("edge %d = %s -> %s, want %s -> %s", i, e.Source, e.Target, r.StartStation, r.EndStation)
+		}
+		if _, ok := nodes[e.Source]; !ok {
+			t.Errorf("edge %d source %q is not a node", i, e.Source)
+		}
+		if _, ok := nodes[e.Target]; !ok {
+			t.Errorf("edge %d target %q is not a node", i, e.Target)
+		}
+	}
+	if got := doc.Graph.Edges[1].Data; !reflect.DeepEqual(got, []graphMLData{{"distance", "1.25"}, {"stops", "2"}}) {
+		t.Errorf("edge 1 data = %v", got)
+	}
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	skipped, err := writeGeoJSON(&buf, exportTestRoutes, exportTestCoords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(skipped, exportTestRoutes[1:2]) {
+		t.Errorf("skipped = %v, want the route to the station without coordinates", skipped)
+	}
+
+	var collection struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type string
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, f := range collection.Features {
+		counts[f.Geometry.Type]++
+	}
+	if collection.Type != "FeatureCollection" || counts["Point"] != 3 || counts["LineString"] != 2 {
+		t.Errorf("got %s with %v, want 3 points and 2 lines", collection.Type, counts)
+	}
+}
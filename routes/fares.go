@@ -0,0 +1,327 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var ErrNoZone = errors.New("station has no fare zone")
+
+// FareRules configure the fare engine. Every component is optional; a leg
+// costs Flat + PerStop*stops + PerKm*km + the zone fare, kept between
+// MinFare and MaxLegFare. Legs after the first are transfers: the first
+// FreeTransfers of them do not pay Flat, and all of them get
+// TransferDiscount (a fraction) off. The journey total is capped at
+// MaxJourneyFare.
+type FareRules struct {
+	Currency string  `json:"currency"`
+	Flat     float64 `json:"flat"`
+	PerStop  float64 `json:"per_stop"`
+	PerKm    float64 `json:"per_km"`
+
+	// Zones maps stations to zone numbers. ZoneFares[i] is the fare for a
+	// leg touching i+1 zones; longer legs pay the last entry.
+	Zones     map[string]int `json:"zones"`
+	ZoneFares []float64      `json:"zone_fares"`
+
+	MinFare        float64 `json:"min_fare"`
+	MaxLegFare     float64 `json:"max_leg_fare"`
+	MaxJourneyFare float64 `json:"max_journey_fare"`
+
+	FreeTransfers    int     `json:"free_transfers"`
+	TransferDiscount float64 `json:"transfer_discount"`
+
+	// Rounding rounds every leg to a multiple of this amount, e.g. 0.05.
+	Rounding float64 `json:"rounding"`
+}
+
+func (f *FareRules) validate() error {
+	for name, v := range map[string]float64{
+		"flat": f.Flat, "per_stop": f.PerStop, "per_km": f.PerKm, "min_fare": f.MinFare,
+		"max_leg_fare": f.MaxLegFare, "max_journey_fare": f.MaxJourneyFare, "rounding": f.Rounding,
+	} {
+		if v < 0 || math.IsNaN(v) {
+			return fmt.Errorf("%s: %w", name, ErrNegative)
+		}
+	}
+	if f.TransferDiscount < 0 || f.TransferDiscount > 1 {
+		return errors.New("transfer_discount must be between 0 and 1")
+	}
+	if f.MaxLegFare > 0 && f.MaxLegFare < f.MinFare {
+		return fmt.Errorf("max_leg_fare %g is below min_fare %g", f.MaxLegFare, f.MinFare)
+	}
+	if f.FreeTransfers < 0 {
+		return fmt.Errorf("free_transfers: %w", ErrNegative)
+	}
+	for i, v := range f.ZoneFares {
+		if v < 0 || math.IsNaN(v) {
+			return fmt.Errorf("zone_fares[%d]: %w", i, ErrNegative)
+		}
+	}
+	if len(f.Zones) > 0 && len(f.ZoneFares) == 0 {
+		return errors.New("zones are set but zone_fares is empty")
+	}
+	return nil
+}
+
+func readFareRules(r io.Reader) (*FareRules, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	rules := &FareRules{}
+	if err := decoder.Decode(rules); err != nil {
+		return nil, err
+	}
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func readFareRulesFromFile(filename string) (*FareRules, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rules, err := readFareRules(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return rules, nil
+}
+
+// LegFare is the price of one leg split into its components.
+type LegFare struct {
+	Route    Route   `json:"route"`
+	Flat     float64 `json:"flat"`
+	Stops    float64 `json:"stops"`
+	Distance float64 `json:"distance"`
+	Zones    float64 `json:"zones"`
+	Discount float64 `json:"discount"`
+	Total    float64 `json:"total"`
+}
+
+type Fare struct {
+	Legs     []LegFare `json:"legs"`
+	Capped   float64   `json:"capped,omitempty"`
+	Total    float64   `json:"total"`
+	Currency string    `json:"currency,omitempty"`
+}
+
+func (f *FareRules) round(amount float64) float64 {
+	if f.Rounding <= 0 {
+		return math.Round(amount*100) / 100
+	}
+	return math.Round(math.Round(amount/f.Rounding)*f.Rounding*100) / 100
+}
+
+func (f *FareRules) zoneFare(r Route) (float64, error) {
+	if len(f.ZoneFares) == 0 {
+		return 0, nil
+	}
+	for _, name := range []string{r.StartStation, r.EndStation} {
+		if _, ok := f.Zones[name]; !ok {
+			return 0, fmt.Errorf("%w: %s", ErrNoZone, name)
+		}
+	}
+	zones := f.Zones[r.EndStation] - f.Zones[r.StartStation]
+	if zones < 0 {
+		zones = -zones
+	}
+	return f.ZoneFares[min(zones, len(f.ZoneFares)-1)], nil
+}
+
+// priceLeg prices a route ridden as the index-th leg of a journey.
+func (f *FareRules) priceLeg(r Route, index int) (LegFare, error) {
+	zone, err := f.zoneFare(r)
+	if err != nil {
+		return LegFare{}, err
+	}
+	leg := LegFare{
+		Route:    r,
+		Flat:     f.Flat,
+		Stops:    f.PerStop * float64(r.NumStops),
+		Distance: f.PerKm * r.Distance,
+		Zones:    zone,
+	}
+	if index > 0 && index <= f.FreeTransfers {
+		leg.Flat = 0
+	}
+	total := leg.Flat + leg.Stops + leg.Distance + leg.Zones
+	total = math.Max(total, f.MinFare)
+	if f.MaxLegFare > 0 {
+		total = math.Min(total, f.MaxLegFare)
+	}
+	if index > 0 {
+		leg.Discount = f.round(total * f.TransferDiscount)
+	}
+	leg.Total = f.round(total) - leg.Discount
+	return leg, nil
+}
+
+// price prices the legs of a journey in order; a single route is a journey
+// with one leg.
+func (f *FareRules) price(legs []Route) (Fare, error) {
+	fare := Fare{Currency: f.Currency, Legs: []LegFare{}}
+	for i, r := range legs {
+		leg, err := f.priceLeg(r, i)
+		if err != nil {
+			return Fare{}, err
+		}
+		fare.Legs = append(fare.Legs, leg)
+		fare.Total += leg.Total
+	}
+	fare.Total = f.round(fare.Total)
+	if f.MaxJourneyFare > 0 && fare.Total > f.MaxJourneyFare {
+		fare.Capped = f.round(fare.Total - f.MaxJourneyFare)
+		fare.Total = f.MaxJourneyFare
+	}
+	return fare, nil
+}
+
+func writeRouteFares(w io.Writer, routes []Route, fares []Fare, currency string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "START\tEND\tSTOPS\tDISTANCE\tFARE %s\n", currency)
+	for i, r := range routes {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t%.2f\n", r.StartStation, r.EndStation, r.NumStops, r.Distance, fares[i].Total)
+	}
+	return tw.Flush()
+}
+
+func writeRouteFaresCSV(w io.Writer, routes []Route, fares []Fare) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"start", "end", "stops", "distance", "fare"})
+	for i, r := range routes {
+		writer.Write([]string{r.StartStation, r.EndStation, strconv.Itoa(r.NumStops), formatDistance(r.Distance), formatDistance(fares[i].Total)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// pricedJourney is a planned journey with its fare.
+type pricedJourney struct {
+	Path Path `json:"journey"`
+	Fare Fare `json:"fare"`
+}
+
+// writeJourneyFaresCSV writes one row per leg; journey numbers the
+// journeys from 1 and journey_total repeats the capped total on each leg.
+func writeJourneyFaresCSV(w io.Writer, priced []pricedJourney) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"journey", "start", "end", "flat", "stops", "distance", "zones", "discount", "total", "journey_total"})
+	for i, pj := range priced {
+		for _, leg := range pj.Fare.Legs {
+			writer.Write([]string{
+				strconv.Itoa(i + 1), leg.Route.StartStation, leg.Route.EndStation,
+				formatDistance(leg.Flat), formatDistance(leg.Stops), formatDistance(leg.Distance),
+				formatDistance(leg.Zones), formatDistance(leg.Discount), formatDistance(leg.Total),
+				formatDistance(pj.Fare.Total),
+			})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeJourneyFare(w io.Writer, p Path, fare Fare) error {
+	fmt.Fprintf(w, "%s: %.2f %s\n", strings.Join(p.Stations, " -> "), fare.Total, fare.Currency)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  LEG\tFLAT\tSTOPS\tDISTANCE\tZONES\tDISCOUNT\tTOTAL")
+	for _, leg := range fare.Legs {
+		fmt.Fprintf(tw, "  %s -> %s\t%.2f\t%.2f\t%.2f\t%.2f\t-%.2f\t%.2f\n", leg.Route.StartStation, leg.Route.EndStation,
+			leg.Flat, leg.Stops, leg.Distance, leg.Zones, leg.Discount, leg.Total)
+	}
+	if fare.Capped > 0 {
+		fmt.Fprintf(tw, "  journey cap\t\t\t\t\t-%.2f\t\n", fare.Capped)
+	}
+	return tw.Flush()
+}
+
+// runFare prices every route of the file, or with <from> <to> the journeys
+// found by the journey planner.
+func runFare(args []string) {
+	fs := flag.NewFlagSet("fare", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	rulesFile := fs.String("rules", "fares.json", "fare rules JSON file")
+	k := fs.Int("k", 3, "number of journeys to price between two stations")
+	objective := fs.String("by", "distance", "journey ranking objective: distance, stops or transfers")
+	maxTransfers := fs.Int("max-transfers", -1, "maximum number of transfers (-1 for no limit)")
+	fs.Parse(args)
+
+	rules, err := readFareRulesFromFile(*rulesFile)
+	if err != nil {
+		fmt.Println("Error reading fare rules:", err)
+		return
+	}
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	format := *opts.format
+
+	if fs.NArg() < 2 {
+		fares := make([]Fare, len(routes))
+		for i, r := range routes {
+			if fares[i], err = rules.price([]Route{r}); err != nil {
+				fmt.Println("Error pricing route:", err)
+				return
+			}
+		}
+		switch format {
+		case "table":
+			err = writeRouteFares(os.Stdout, routes, fares, rules.Currency)
+		case "csv":
+			err = writeRouteFaresCSV(os.Stdout, routes, fares)
+		default:
+			err = writeJSON(os.Stdout, fares)
+		}
+		if err != nil {
+			fmt.Println("Error writing output:", err)
+		}
+		return
+	}
+
+	c := JourneyConstraints{MaxTransfers: *maxTransfers, MaxStops: -1, MaxDistance: -1}
+	journeys, err := planJourneys(routes, fs.Arg(0), fs.Arg(1), c, *objective, *k)
+	if err != nil {
+		fmt.Println("Error planning journey:", err)
+		return
+	}
+	var priced []pricedJourney
+	for _, p := range journeys {
+		fare, err := rules.price(p.Legs)
+		if err != nil {
+			fmt.Println("Error pricing journey:", err)
+			return
+		}
+		priced = append(priced, pricedJourney{p, fare})
+	}
+	if format != "table" {
+		if format == "csv" {
+			err = writeJourneyFaresCSV(os.Stdout, priced)
+		} else {
+			err = writeJSON(os.Stdout, priced)
+		}
+		if err != nil {
+			fmt.Println("Error writing output:", err)
+		}
+		return
+	}
+	for _, pj := range priced {
+		if err := writeJourneyFare(os.Stdout, pj.Path, pj.Fare); err != nil {
+			fmt.Println("Error writing output:", err)
+			return
+		}
+	}
+}
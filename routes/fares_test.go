@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func loadTestFareRules(t *testing.T) *FareRules {
+	t.Helper()
+	rules, err := readFareRulesFromFile("fares.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+func TestPriceSingleLeg(t *testing.T) {
+	rules := loadTestFareRules(t)
+	tests := []struct {
+		name  string
+		route Route
+		want  float64
+	}{
+		// 8 flat + 3*0.5 + 10.5*0.2 + 0 zones = 11.6, rounded to 0.5.
+		{"rounded", Route{"Station A", "Station B", 3, 10.5}, 11.5},
+		// 8 + 2 + 3 + 2 (one zone crossed) = 15.
+		{"one zone", Route{"Station C", "Station D", 4, 15}, 15},
+		// 8 + 10 + 10 + 4 (two zones) = 32, capped by max_leg_fare.
+		{"max leg fare", Route{"Station A", "Station D", 20, 50}, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fare, err := rules.price([]Route{tt.route})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fare.Total != tt.want || fare.Legs[0].Total != tt.want || fare.Currency != "UAH" {
+				t.Errorf("fare = %+v, want a total of %g UAH", fare, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceJourney(t *testing.T) {
+	rules := loadTestFareRules(t)
+
+	// Leg 1: 32 capped at 20. Leg 2 is the free transfer, so no flat fare:
+	// 15 + 8 + 2 = 25, capped at 20, minus the 25% discount of 5 = 15.
+	// The journey total of 35 is capped at max_journey_fare 30.
+	fare, err := rules.price([]Route{{"Station A", "Station D", 20, 50}, {"Station D", "Station C", 30, 40}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leg := fare.Legs[1]; leg.Flat != 0 || leg.Discount != 5 || leg.Total != 15 {
+		t.Errorf("second leg = %+v, want no flat fare, a discount of 5 and a total of 15", leg)
+	}
+	if fare.Total != 30 || fare.Capped != 5 {
+		t.Errorf("total %g capped by %g, want 30 capped by 5", fare.Total, fare.Capped)
+	}
+
+	// The second transfer pays the flat fare again: legs of 11.5, then
+	// max(4.6, 8) - 2 = 6, then 8 + 2 + 3 + 2 = 15 minus 4 = 11.
+	fare, err = rules.price([]Route{
+		{"Station A", "Station B", 3, 10.5},
+		{"Station B", "Station C", 2, 8},
+		{"Station C", "Station D", 4, 15},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var totals []float64
+	for _, leg := range fare.Legs {
+		totals = append(totals, leg.Total)
+	}
+	if !slices.Equal(totals, []float64{11.5, 6, 11}) || fare.Legs[2].Flat != 8 || fare.Total != 28.5 || fare.Capped != 0 {
+		t.Errorf("leg totals %v, total %g, capped %g; want 11.5, 6, 11 with a full flat fare on the third", totals, fare.Total, fare.Capped)
+	}
+}
+
+func TestPriceMinFareAndRounding(t *testing.T) {
+	rules := &FareRules{PerKm: 1, MinFare: 3}
+	if fare, _ := rules.price([]Route{{"A", "B", 1, 1}}); fare.Total != 3 {
+		t.Errorf("total = %g, want min_fare 3", fare.Total)
+	}
+	// Without rounding amounts are kept to cents.
+	if fare, _ := rules.price([]Route{{"A", "B", 1, 3.14159}}); fare.Total != 3.14 {
+		t.Errorf("total = %g, want 3.14", fare.Total)
+	}
+	rules.Rounding = 0.05
+	if fare, _ := rules.price([]Route{{"A", "B", 1, 3.14159}}); fare.Total != 3.15 {
+		t.Errorf("total = %g, want 3.15", fare.Total)
+	}
+}
+
+func TestPriceUnknownZone(t *testing.T) {
+	rules := loadTestFareRules(t)
+	if _, err := rules.price([]Route{{"Station A", "Nowhere", 1, 1}}); !errors.Is(err, ErrNoZone) {
+		t.Errorf("err = %v, want ErrNoZone", err)
+	}
+}
+
+func TestFareRulesValidate(t *testing.T) {
+	for _, config := range []string{
+		`{"flat": -1}`,
+		`{"zone_fares": [0, -2]}`,
+		`{"min_fare": 10, "max_leg_fare": 5}`,
+		`{"transfer_discount": 1.5}`,
+		`{"free_transfers": -1}`,
+		`{"zones": {"A": 1}}`,
+		`{"unknown": 1}`,
+	} {
+		if _, err := readFareRules(strings.NewReader(config)); err == nil {
+			t.Errorf("%s was accepted", config)
+		}
+	}
+	rules := &FareRules{ZoneFares: []float64{1, math.NaN()}}
+	if err := rules.validate(); !errors.Is(err, ErrNegative) {
+		t.Errorf("NaN zone fare: err = %v, want ErrNegative", err)
+	}
+}
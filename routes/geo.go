@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const earthRadiusKm = 6371.0
+
+var ErrInvalidCoordinate = errors.New("invalid coordinate")
+
+// Station is a named stop with WGS84 coordinates in degrees.
+type Station struct {
+	Name string  `json:"name"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// haversine returns the great-circle distance in km between two points
+// given in degrees.
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func (s Station) distanceTo(o Station) float64 {
+	return haversine(s.Lat, s.Lon, o.Lat, o.Lon)
+}
+
+// readStations parses "name,lat,lon" rows; a header row naming the columns
+// (name/station, lat/latitude, lon/lng/longitude) is optional.
+func readStations(r io.Reader) ([]Station, error) {
+	buffered := bufio.NewReader(r)
+	peek, _ := buffered.Peek(4096)
+	firstLine, _, _ := strings.Cut(string(peek), "\n")
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = sniffDelimiter(firstLine)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	positions := [3]int{0, 1, 2}
+	columns := [3]string{"name", "lat", "lon"}
+	aliases := map[string]int{
+		"name": 0, "station": 0, "stopname": 0,
+		"lat": 1, "latitude": 1, "stoplat": 1,
+		"lon": 2, "lng": 2, "longitude": 2, "stoplon": 2,
+	}
+
+	var stations []Station
+	seen := make(map[string]bool)
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if first {
+			first = false
+			header := [3]int{-1, -1, -1}
+			for i, field := range record {
+				if col, ok := aliases[normalizeHeader(field)]; ok && header[col] < 0 {
+					header[col] = i
+				}
+			}
+			if header[0] >= 0 && header[1] >= 0 && header[2] >= 0 {
+				positions = header
+				continue
+			}
+		}
+
+		var values [3]string
+		for col, pos := range positions {
+			if pos >= len(record) {
+				return nil, &RowError{Line: line, Column: columns[col], Err: ErrMissingField}
+			}
+			values[col] = strings.TrimSpace(record[pos])
+		}
+		if values[0] == "" {
+			return nil, &RowError{Line: line, Column: "name", Err: ErrEmptyStation}
+		}
+		lat, err := strconv.ParseFloat(values[1], 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return nil, &RowError{Line: line, Column: "lat", Value: values[1], Err: ErrInvalidCoordinate}
+		}
+		lon, err := strconv.ParseFloat(values[2], 64)
+		if err != nil || lon < -180 || lon > 180 {
+			return nil, &RowError{Line: line, Column: "lon", Value: values[2], Err: ErrInvalidCoordinate}
+		}
+		if seen[values[0]] {
+			return nil, &RowError{Line: line, Column: "name", Value: values[0], Err: errors.New("duplicate station")}
+		}
+		seen[values[0]] = true
+		stations = append(stations, Station{Name: values[0], Lat: lat, Lon: lon})
+	}
+	return stations, nil
+}
+
+func readStationsFromFile(filename string) ([]Station, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stations, err := readStations(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return stations, nil
+}
+
+func stationsByName(stations []Station) map[string]Station {
+	byName := make(map[string]Station, len(stations))
+	for _, s := range stations {
+		byName[s.Name] = s
+	}
+	return byName
+}
+
+// haversineHeuristic estimates the remaining distance to target as the
+// straight-line distance scaled down by the smallest ratio of route
+// distance to straight-line distance in routes. checkRouteDistances lets
+// routes be slightly shorter than the straight line, so without the scaling
+// the estimate could overestimate and A* would miss the shortest path.
+// A route to or from a station without coordinates gives no bound at all,
+// so the estimate is then 0 everywhere, as it is for such stations.
+func haversineHeuristic(stations []Station, routes []Route, target string) func(string) float64 {
+	byName := stationsByName(stations)
+	scale := 1.0
+	for _, r := range routes {
+		a, aOK := byName[r.StartStation]
+		b, bOK := byName[r.EndStation]
+		if !aOK || !bOK {
+			scale = 0
+			break
+		}
+		if straight := a.distanceTo(b); straight > 0 {
+			scale = min(scale, max(r.Distance, 0)/straight)
+		}
+	}
+	t, ok := byName[target]
+	return func(name string) float64 {
+		s, known := byName[name]
+		if !ok || !known {
+			return 0
+		}
+		return scale * s.distanceTo(t)
+	}
+}
+
+// DistanceIssue reports a route whose CSV distance disagrees with the
+// straight-line distance between its stations.
+type DistanceIssue struct {
+	Route    Route   `json:"route"`
+	Straight float64 `json:"straight_km"`
+	Ratio    float64 `json:"ratio"`
+	Problem  string  `json:"problem"`
+}
+
+// checkRouteDistances flags routes that are shorter than the straight line
+// by more than tolerance (a fraction), longer than maxDetour times the
+// straight line, or whose stations have no coordinates.
+func checkRouteDistances(routes []Route, stations []Station, tolerance, maxDetour float64) []DistanceIssue {
+	byName := stationsByName(stations)
+	var issues []DistanceIssue
+	for _, r := range routes {
+		a, aOK := byName[r.StartStation]
+		b, bOK := byName[r.EndStation]
+		if !aOK || !bOK {
+			issues = append(issues, DistanceIssue{Route: r, Problem: "station has no coordinates"})
+			continue
+		}
+		straight := a.distanceTo(b)
+		issue := DistanceIssue{Route: r, Straight: math.Round(straight*100) / 100}
+		if straight > 0 {
+			issue.Ratio = math.Round(r.Distance/straight*1000) / 1000
+		}
+		switch {
+		case r.Distance < straight*(1-tolerance):
+			issue.Problem = "shorter than the straight-line distance"
+		case straight > 0 && r.Distance > straight*maxDetour:
+			issue.Problem = fmt.Sprintf("more than %gx the straight-line distance", maxDetour)
+		default:
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// kdTree indexes stations by their position on the unit sphere, so the
+// Euclidean (chord) distance used for pruning orders points exactly like
+// the great-circle distance.
+type kdTree struct {
+	root *kdNode
+}
+
+type kdNode struct {
+	station     Station
+	point       [3]float64
+	axis        int
+	left, right *kdNode
+}
+
+func toCartesian(lat, lon float64) [3]float64 {
+	phi, lambda := toRadians(lat), toRadians(lon)
+	return [3]float64{math.Cos(phi) * math.Cos(lambda), math.Cos(phi) * math.Sin(lambda), math.Sin(phi)}
+}
+
+func chordDistance(a, b [3]float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return math.Sqrt(sum)
+}
+
+// chordForKm converts a great-circle distance into the matching chord
+// length on the unit sphere.
+func chordForKm(km float64) float64 {
+	return 2 * math.Sin(math.Min(km/earthRadiusKm, math.Pi)/2)
+}
+
+func newKDTree(stations []Station) *kdTree {
+	nodes := make([]*kdNode, len(stations))
+	for i, s := range stations {
+		nodes[i] = &kdNode{station: s, point: toCartesian(s.Lat, s.Lon)}
+	}
+	var build func(nodes []*kdNode, depth int) *kdNode
+	build = func(nodes []*kdNode, depth int) *kdNode {
+		if len(nodes) == 0 {
+			return nil
+		}
+		axis := depth % 3
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].point[axis] < nodes[j].point[axis] })
+		mid := len(nodes) / 2
+		n := nodes[mid]
+		n.axis = axis
+		n.left = build(nodes[:mid], depth+1)
+		n.right = build(nodes[mid+1:], depth+1)
+		return n
+	}
+	return &kdTree{root: build(nodes, 0)}
+}
+
+// StationDistance is a query result with its distance from the query point.
+type StationDistance struct {
+	Station
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// nearest returns the k stations closest to (lat, lon), closest first.
+func (t *kdTree) nearest(lat, lon float64, k int) []StationDistance {
+	target := toCartesian(lat, lon)
+	type found struct {
+		node  *kdNode
+		chord float64
+	}
+	var best []found
+	var search func(n *kdNode)
+	search = func(n *kdNode) {
+		if n == nil || k < 1 {
+			return
+		}
+		d := chordDistance(n.point, target)
+		if len(best) < k || d < best[len(best)-1].chord {
+			i := sort.Search(len(best), func(i int) bool { return best[i].chord > d })
+			best = append(best, found{})
+			copy(best[i+1:], best[i:])
+			best[i] = found{n, d}
+			if len(best) > k {
+				best = best[:k]
+			}
+		}
+		diff := target[n.axis] - n.point[n.axis]
+		near, far := n.left, n.right
+		if diff > 0 {
+			near, far = far, near
+		}
+		search(near)
+		if len(best) < k || math.Abs(diff) < best[len(best)-1].chord {
+			search(far)
+		}
+	}
+	search(t.root)
+
+	result := make([]StationDistance, len(best))
+	for i, f := range best {
+		result[i] = StationDistance{f.node.station, haversine(lat, lon, f.node.station.Lat, f.node.station.Lon)}
+	}
+	return result
+}
+
+// within returns every station at most radiusKm from (lat, lon), closest
+// first.
+func (t *kdTree) within(lat, lon, radiusKm float64) []StationDistance {
+	target := toCartesian(lat, lon)
+	limit := chordForKm(radiusKm)
+	var result []StationDistance
+	var search func(n *kdNode)
+	search = func(n *kdNode) {
+		if n == nil {
+			return
+		}
+		if chordDistance(n.point, target) <= limit {
+			if d := haversine(lat, lon, n.station.Lat, n.station.Lon); d <= radiusKm {
+				result = append(result, StationDistance{n.station, d})
+			}
+		}
+		diff := target[n.axis] - n.point[n.axis]
+		if diff <= limit {
+			search(n.left)
+		}
+		if diff >= -limit {
+			search(n.right)
+		}
+	}
+	search(t.root)
+	sort.Slice(result, func(i, j int) bool { return result[i].DistanceKm < result[j].DistanceKm })
+	return result
+}
+
+// parseLatLon parses "lat,lon" in degrees.
+func parseLatLon(s string) (lat, lon float64, err error) {
+	latText, lonText, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("%w %q, expected lat,lon", ErrInvalidCoordinate, s)
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+	lon, err2 := strconv.ParseFloat(strings.TrimSpace(lonText), 64)
+	if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("%w %q, expected lat,lon", ErrInvalidCoordinate, s)
+	}
+	return lat, lon, nil
+}
+
+func writeStationDistances(w io.Writer, results []StationDistance, format string) error {
+	if format == "json" {
+		if results == nil {
+			results = []StationDistance{}
+		}
+		return writeJSON(w, results)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATION\tLAT\tLON\tDISTANCE")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%.5f\t%.5f\t%.2f\n", r.Name, r.Lat, r.Lon, r.DistanceKm)
+	}
+	return tw.Flush()
+}
+
+// runStations answers -near and -within queries against a stations file.
+// A station name may be given instead of coordinates.
+func runStations(args []string) {
+	fs := flag.NewFlagSet("stations", flag.ExitOnError)
+	stationsFile := fs.String("stations", "stations.csv", "stations file with name,lat,lon")
+	near := fs.String("near", "", "query point as lat,lon or a station name")
+	k := fs.Int("k", 3, "number of nearest stations")
+	radius := fs.Float64("radius", 0, "list every station within this many km instead of the nearest k")
+	format := fs.String("format", "table", "output format: table or json")
+	fs.Parse(args)
+
+	if *near == "" {
+		fmt.Println("Usage: go run *.go stations -near <lat,lon|station> [-k n | -radius km]")
+		return
+	}
+	stations, err := readStationsFromFile(*stationsFile)
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	lat, lon, err := parseLatLon(*near)
+	if err != nil {
+		s, ok := stationsByName(stations)[*near]
+		if !ok {
+			fmt.Println(err)
+			return
+		}
+		lat, lon = s.Lat, s.Lon
+	}
+
+	tree := newKDTree(stations)
+	var results []StationDistance
+	if *radius > 0 {
+		results = tree.within(lat, lon, *radius)
+	} else {
+		results = tree.nearest(lat, lon, *k)
+	}
+	if err := writeStationDistances(os.Stdout, results, *format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
+
+func runCheckDistances(args []string) {
+	fs := flag.NewFlagSet("check-distances", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	stationsFile := fs.String("stations", "stations.csv", "stations file with name,lat,lon")
+	tolerance := fs.Float64("tolerance", 0.05, "allowed fraction below the straight-line distance")
+	maxDetour := fs.Float64("max-detour", 2.0, "maximum ratio of route distance to straight-line distance")
+	fs.Parse(args)
+
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	stations, err := readStationsFromFile(*stationsFile)
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+
+	issues := checkRouteDistances(routes, stations, *tolerance, *maxDetour)
+	if *opts.format == "json" || *opts.format == "ndjson" {
+		if issues == nil {
+			issues = []DistanceIssue{}
+		}
+		if err := writeJSON(os.Stdout, issues); err != nil {
+			fmt.Println("Error writing output:", err)
+		}
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tEND\tDISTANCE\tSTRAIGHT\tRATIO\tPROBLEM")
+	for _, issue := range issues {
+		r := issue.Route
+		fmt.Fprintf(tw, "%s\t%s\t%.2f\t%.2f\t%.3f\t%s\n", r.StartStation, r.EndStation, r.Distance, issue.Straight, issue.Ratio, issue.Problem)
+	}
+	tw.Flush()
+	fmt.Printf("%d of %d routes inconsistent with station coordinates\n", len(issues), len(routes))
+}
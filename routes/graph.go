@@ -0,0 +1,272 @@
+package main
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrUnknownStation = errors.New("unknown station")
+	ErrNoPath         = errors.New("no path between stations")
+	ErrNegativeCycle  = errors.New("graph contains a negative cycle")
+)
+
+// Edge is a directed connection between two stations backed by a Route.
+type Edge struct {
+	From   string
+	To     string
+	Weight float64
+	Route  Route
+}
+
+type StationGraph struct {
+	adjList map[string][]Edge
+}
+
+// WeightFunc assigns a cost to travelling along a route.
+type WeightFunc func(Route) float64
+
+func weightByDistance(r Route) float64 {
+	return r.Distance
+}
+
+func weightByStops(r Route) float64 {
+	return float64(r.NumStops)
+}
+
+func weightFuncByName(name string) (WeightFunc, error) {
+	switch name {
+	case "distance":
+		return weightByDistance, nil
+	case "stops":
+		return weightByStops, nil
+	case "hops":
+		return func(Route) float64 { return 1 }, nil
+	}
+	return nil, fmt.Errorf("unknown weight %q, expected distance, stops or hops", name)
+}
+
+func newStationGraph() *StationGraph {
+	return &StationGraph{adjList: make(map[string][]Edge)}
+}
+
+func (g *StationGraph) addEdge(e Edge) {
+	g.adjList[e.From] = append(g.adjList[e.From], e)
+	if _, ok := g.adjList[e.To]; !ok {
+		g.adjList[e.To] = nil
+	}
+}
+
+// buildStationGraph turns every route into an edge weighted by weight. With
+// bidirectional set, each route can also be travelled from end to start.
+func buildStationGraph(routes []Route, weight WeightFunc, bidirectional bool) *StationGraph {
+	g := newStationGraph()
+	for _, r := range routes {
+		g.addEdge(Edge{From: r.StartStation, To: r.EndStation, Weight: weight(r), Route: r})
+		if bidirectional {
+			g.addEdge(Edge{From: r.EndStation, To: r.StartStation, Weight: weight(r), Route: r})
+		}
+	}
+	return g
+}
+
+func (g *StationGraph) hasStation(name string) bool {
+	_, ok := g.adjList[name]
+	return ok
+}
+
+func (g *StationGraph) stations() []string {
+	names := make([]string, 0, len(g.adjList))
+	for name := range g.adjList {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (g *StationGraph) hasNegativeWeights() bool {
+	for _, edges := range g.adjList {
+		for _, e := range edges {
+			if e.Weight < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *StationGraph) checkStations(from, to string) error {
+	if !g.hasStation(from) {
+		return fmt.Errorf("%w: %s", ErrUnknownStation, from)
+	}
+	if !g.hasStation(to) {
+		return fmt.Errorf("%w: %s", ErrUnknownStation, to)
+	}
+	return nil
+}
+
+// Path is an itinerary between two stations. Legs are the routes taken in
+// order; Cost is the sum of edge weights used by the search.
+type Path struct {
+	Stations []string `json:"stations"`
+	Legs     []Route  `json:"legs"`
+	Cost     float64  `json:"cost"`
+	Distance float64  `json:"distance"`
+	NumStops int      `json:"stops"`
+}
+
+func buildPath(from, to string, prev map[string]Edge, cost float64) Path {
+	var edges []Edge
+	for at := to; at != from; {
+		e := prev[at]
+		edges = append(edges, e)
+		at = e.From
+	}
+	p := Path{Stations: []string{from}, Cost: cost}
+	for i := len(edges) - 1; i >= 0; i-- {
+		e := edges[i]
+		p.Legs = append(p.Legs, e.Route)
+		p.Stations = append(p.Stations, e.To)
+		p.Distance += e.Route.Distance
+		p.NumStops += e.Route.NumStops
+	}
+	return p
+}
+
+type queueItem struct {
+	station  string
+	priority float64
+}
+
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int           { return len(q) }
+func (q priorityQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q priorityQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *priorityQueue) Push(x any)        { *q = append(*q, x.(queueItem)) }
+func (q *priorityQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// dijkstra finds the cheapest path for graphs without negative weights.
+func dijkstra(g *StationGraph, from, to string) (Path, error) {
+	return aStar(g, from, to, func(string) float64 { return 0 })
+}
+
+// aStar is Dijkstra guided by heuristic, which must never overestimate the
+// remaining cost to the target for the result to be optimal.
+func aStar(g *StationGraph, from, to string, heuristic func(station string) float64) (Path, error) {
+	if err := g.checkStations(from, to); err != nil {
+		return Path{}, err
+	}
+	if g.hasNegativeWeights() {
+		return Path{}, errors.New("dijkstra and A* require non-negative weights")
+	}
+
+	// A station is expanded again whenever a cheaper way to it turns up,
+	// even after it was closed, so a heuristic that is admissible but not
+	// consistent still yields the optimal path. Queue entries whose
+	// priority no longer matches the best known distance are stale.
+	dist := map[string]float64{from: 0}
+	prev := make(map[string]Edge)
+	queue := &priorityQueue{{from, heuristic(from)}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(queueItem)
+		if item.priority > dist[item.station]+heuristic(item.station) {
+			continue
+		}
+		if item.station == to {
+			return buildPath(from, to, prev, dist[to]), nil
+		}
+		for _, e := range g.adjList[item.station] {
+			d := dist[item.station] + e.Weight
+			if old, ok := dist[e.To]; !ok || d < old {
+				dist[e.To] = d
+				prev[e.To] = e
+				heap.Push(queue, queueItem{e.To, d + heuristic(e.To)})
+			}
+		}
+	}
+	return Path{}, fmt.Errorf("%w: %s -> %s", ErrNoPath, from, to)
+}
+
+// bellmanFord handles arbitrary weights and reports negative cycles that are
+// reachable from the start station.
+func bellmanFord(g *StationGraph, from, to string) (Path, error) {
+	if err := g.checkStations(from, to); err != nil {
+		return Path{}, err
+	}
+
+	dist := map[string]float64{from: 0}
+	prev := make(map[string]Edge)
+	relax := func() bool {
+		changed := false
+		for station, edges := range g.adjList {
+			d, ok := dist[station]
+			if !ok {
+				continue
+			}
+			for _, e := range edges {
+				if old, ok := dist[e.To]; !ok || d+e.Weight < old {
+					dist[e.To] = d + e.Weight
+					prev[e.To] = e
+					changed = true
+				}
+			}
+		}
+		return changed
+	}
+	for i := 1; i < len(g.adjList); i++ {
+		if !relax() {
+			break
+		}
+	}
+	if relax() {
+		return Path{}, ErrNegativeCycle
+	}
+
+	cost, ok := dist[to]
+	if !ok {
+		return Path{}, fmt.Errorf("%w: %s -> %s", ErrNoPath, from, to)
+	}
+	return buildPath(from, to, prev, cost), nil
+}
+
+// shortestPath picks the algorithm by name; "auto" uses Dijkstra unless the
+// graph has negative weights. heuristic is only used by "astar"; nil means
+// no estimate, which makes A* behave like Dijkstra.
+func shortestPath(g *StationGraph, from, to, algorithm string, heuristic func(station string) float64) (Path, error) {
+	switch algorithm {
+	case "auto":
+		if g.hasNegativeWeights() {
+			return bellmanFord(g, from, to)
+		}
+		return dijkstra(g, from, to)
+	case "dijkstra":
+		return dijkstra(g, from, to)
+	case "bellman-ford":
+		return bellmanFord(g, from, to)
+	case "astar":
+		if heuristic == nil {
+			return dijkstra(g, from, to)
+		}
+		return aStar(g, from, to, heuristic)
+	}
+	return Path{}, fmt.Errorf("unknown algorithm %q", algorithm)
+}
+
+func formatPath(p Path) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d legs, %d stops, %.2f km, cost %.2f)\n",
+		strings.Join(p.Stations, " -> "), len(p.Legs), p.NumStops, p.Distance, p.Cost)
+	for i, leg := range p.Legs {
+		fmt.Fprintf(&b, "  %d. %s -> %s: %d stops, %.2f km\n", i+1, p.Stations[i], p.Stations[i+1], leg.NumStops, leg.Distance)
+	}
+	return b.String()
+}
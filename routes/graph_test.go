@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAStarReopensClosedStations(t *testing.T) {
+	// S -> A -> B -> T costs 1 + 1 + 5 = 7; S -> B -> T costs 3 + 5 = 8.
+	// The heuristic is admissible but not consistent: A looks expensive, so
+	// B is closed via the direct leg first and must be reopened.
+	routes := []Route{
+		{"S", "A", 1, 1},
+		{"A", "B", 1, 1},
+		{"S", "B", 1, 3},
+		{"B", "T", 1, 5},
+	}
+	g := buildStationGraph(routes, func(r Route) float64 { return r.Distance }, false)
+	h := map[string]float64{"S": 0, "A": 6, "B": 0, "T": 0}
+	path, err := aStar(g, "S", "T", func(s string) float64 { return h[s] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.Cost != 7 || path.Distance != 7 || len(path.Legs) != 3 {
+		t.Errorf("path = %+v, want S -> A -> B -> T costing 7", path)
+	}
+}
+
+func TestHaversineHeuristicAdmissible(t *testing.T) {
+	stations := []Station{{"A", 0, 0}, {"B", 0, 1}, {"C", 0, 2}}
+	straight := stations[0].distanceTo(stations[1])
+	routes := []Route{
+		{"A", "B", 1, straight * 0.96},
+		{"B", "C", 1, straight * 1.2},
+	}
+	h := haversineHeuristic(stations, routes, "C")
+	if got, limit := h("B"), routes[1].Distance; got > limit {
+		t.Errorf("h(B) = %g overestimates the %g km route", got, limit)
+	}
+	if got := h("A"); got > routes[0].Distance+routes[1].Distance {
+		t.Errorf("h(A) = %g overestimates the remaining distance", got)
+	}
+	if got := h("C"); got != 0 {
+		t.Errorf("h(C) = %g, want 0", got)
+	}
+
+	routes = append(routes, Route{"A", "X", 1, 1})
+	h = haversineHeuristic(stations, routes, "C")
+	if got := h("A"); got != 0 || math.IsNaN(got) {
+		t.Errorf("h(A) = %g, want 0 with a station lacking coordinates", got)
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrMissingGTFSFile = errors.New("required GTFS file is missing")
+
+// gtfsTable is a parsed GTFS text file; each row maps column names to values.
+type gtfsTable []map[string]string
+
+// readGTFSTable reads name from the feed. Optional files that do not exist
+// yield an empty table; required ones yield ErrMissingGTFSFile.
+func readGTFSTable(feed fs.FS, name string, required bool, columns ...string) (gtfsTable, error) {
+	var table gtfsTable
+	_, err := eachGTFSRow(feed, name, required, func(row map[string]string) error {
+		table = append(table, maps.Clone(row))
+		return nil
+	}, columns...)
+	return table, err
+}
+
+// eachGTFSRow streams the rows of name to fn without keeping the file in
+// memory; the row map is reused between calls. It reports whether the file
+// exists, with the same handling of missing files as readGTFSTable.
+func eachGTFSRow(feed fs.FS, name string, required bool, fn func(row map[string]string) error, columns ...string) (bool, error) {
+	file, err := feed.Open(name)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return false, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("%w: %s", ErrMissingGTFSFile, name)
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return true, fmt.Errorf("%s: reading header: %w", name, err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+	for _, column := range columns {
+		if !slices.Contains(header, column) {
+			return true, fmt.Errorf("%s: %w: %s", name, ErrMissingField, column)
+		}
+	}
+
+	row := make(map[string]string, len(header))
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return true, fmt.Errorf("%s: %w", name, err)
+		}
+		clear(row)
+		for i, h := range header {
+			if i < len(record) {
+				row[h] = strings.TrimSpace(record[i])
+			}
+		}
+		if err := fn(row); err != nil {
+			return true, err
+		}
+	}
+}
+
+// openGTFS opens a feed stored either as a zip archive or as a directory of
+// .txt files.
+func openGTFS(path string) (fs.FS, io.Closer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return os.DirFS(path), io.NopCloser(nil), nil
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return archive, archive, nil
+}
+
+func isGTFSPath(path string) bool {
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+type gtfsStop struct {
+	name     string
+	lat, lon float64
+	hasCoord bool
+}
+
+type gtfsPoint struct {
+	lat, lon float64
+	sequence int
+}
+
+// GTFSOptions select which trips are imported. A zero Date imports every
+// trip; otherwise only trips whose service runs on that day are used.
+type GTFSOptions struct {
+	Date time.Time
+	// KeepDuplicates keeps one route per trip instead of one per distinct
+	// start, end, stop count and distance.
+	KeepDuplicates bool
+}
+
+// serviceRunsOn reports whether a calendar.txt row is active on date.
+func serviceRunsOn(row map[string]string, date time.Time) bool {
+	day := strings.ToLower(date.Weekday().String())
+	if row[day] != "1" {
+		return false
+	}
+	ymd := date.Format("20060102")
+	return row["start_date"] <= ymd && ymd <= row["end_date"]
+}
+
+// activeServices returns the service ids running on date, from the weekly
+// patterns of calendar.txt adjusted by the exceptions in calendar_dates.txt.
+// Either file may be missing, but not both. A zero date returns nil.
+func activeServices(feed fs.FS, date time.Time) (map[string]bool, error) {
+	if date.IsZero() {
+		return nil, nil
+	}
+	active := make(map[string]bool)
+	hasCalendar, err := eachGTFSRow(feed, "calendar.txt", false, func(row map[string]string) error {
+		active[row["service_id"]] = serviceRunsOn(row, date)
+		return nil
+	}, "service_id")
+	if err != nil {
+		return nil, err
+	}
+	ymd := date.Format("20060102")
+	hasDates, err := eachGTFSRow(feed, "calendar_dates.txt", false, func(row map[string]string) error {
+		if row["date"] != ymd {
+			return nil
+		}
+		switch row["exception_type"] {
+		case "1":
+			active[row["service_id"]] = true
+		case "2":
+			active[row["service_id"]] = false
+		default:
+			return fmt.Errorf("calendar_dates.txt: service %s: invalid exception_type %q", row["service_id"], row["exception_type"])
+		}
+		return nil
+	}, "service_id", "date", "exception_type")
+	if err != nil {
+		return nil, err
+	}
+	if !hasCalendar && !hasDates {
+		return nil, fmt.Errorf("%w: calendar.txt or calendar_dates.txt is needed to filter by date", ErrMissingGTFSFile)
+	}
+	return active, nil
+}
+
+// importGTFS turns every trip of the feed into a Route from its first to its
+// last stop. NumStops counts the stops after the first one, as for
+// timetable trips. Distance is the length of the trip's shape when the feed
+// has shapes.txt, otherwise the sum of straight-line distances between
+// consecutive stops.
+func importGTFS(feed fs.FS, opts GTFSOptions) ([]Route, error) {
+	stopRows, err := readGTFSTable(feed, "stops.txt", true, "stop_id")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := readGTFSTable(feed, "routes.txt", true, "route_id"); err != nil {
+		return nil, err
+	}
+	tripRows, err := readGTFSTable(feed, "trips.txt", true, "route_id", "service_id", "trip_id")
+	if err != nil {
+		return nil, err
+	}
+	shapeRows, err := readGTFSTable(feed, "shapes.txt", false, "shape_id", "shape_pt_lat", "shape_pt_lon", "shape_pt_sequence")
+	if err != nil {
+		return nil, err
+	}
+
+	stops := make(map[string]gtfsStop)
+	for _, row := range stopRows {
+		s := gtfsStop{name: row["stop_name"]}
+		if s.name == "" {
+			s.name = row["stop_id"]
+		}
+		lat, latErr := strconv.ParseFloat(row["stop_lat"], 64)
+		lon, lonErr := strconv.ParseFloat(row["stop_lon"], 64)
+		if latErr == nil && lonErr == nil {
+			s.lat, s.lon, s.hasCoord = lat, lon, true
+		}
+		stops[row["stop_id"]] = s
+	}
+
+	active, err := activeServices(feed, opts.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	shapes := make(map[string][]gtfsPoint)
+	for _, row := range shapeRows {
+		var p gtfsPoint
+		var errs [3]error
+		p.lat, errs[0] = strconv.ParseFloat(row["shape_pt_lat"], 64)
+		p.lon, errs[1] = strconv.ParseFloat(row["shape_pt_lon"], 64)
+		p.sequence, errs[2] = strconv.Atoi(row["shape_pt_sequence"])
+		if err := errors.Join(errs[:]...); err != nil {
+			return nil, fmt.Errorf("shapes.txt: shape %s: %w", row["shape_id"], ErrInvalidNumber)
+		}
+		shapes[row["shape_id"]] = append(shapes[row["shape_id"]], p)
+	}
+	for _, points := range shapes {
+		sort.Slice(points, func(i, j int) bool { return points[i].sequence < points[j].sequence })
+	}
+
+	// stop_times.txt is by far the largest file of a feed, so it is streamed
+	// and only the calls of trips that will be imported are kept.
+	wanted := make(map[string]bool, len(tripRows))
+	for _, trip := range tripRows {
+		wanted[trip["trip_id"]] = opts.Date.IsZero() || active[trip["service_id"]]
+	}
+	type tripStop struct {
+		stopID   string
+		sequence int
+	}
+	tripStops := make(map[string][]tripStop)
+	_, err = eachGTFSRow(feed, "stop_times.txt", true, func(row map[string]string) error {
+		tripID := row["trip_id"]
+		if !wanted[tripID] {
+			return nil
+		}
+		seq, err := strconv.Atoi(row["stop_sequence"])
+		if err != nil {
+			return fmt.Errorf("stop_times.txt: trip %s: %w: %q", tripID, ErrInvalidNumber, row["stop_sequence"])
+		}
+		tripStops[tripID] = append(tripStops[tripID], tripStop{row["stop_id"], seq})
+		return nil
+	}, "trip_id", "stop_id", "stop_sequence")
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []Route
+	seen := make(map[Route]bool)
+	for _, trip := range tripRows {
+		if !wanted[trip["trip_id"]] {
+			continue
+		}
+		calls := tripStops[trip["trip_id"]]
+		if len(calls) < 2 {
+			continue
+		}
+		sort.Slice(calls, func(i, j int) bool { return calls[i].sequence < calls[j].sequence })
+
+		var distance float64
+		if points := shapes[trip["shape_id"]]; len(points) > 1 {
+			for i := 1; i < len(points); i++ {
+				distance += haversine(points[i-1].lat, points[i-1].lon, points[i].lat, points[i].lon)
+			}
+		} else {
+			for i := 1; i < len(calls); i++ {
+				a, aOK := stops[calls[i-1].stopID]
+				b, bOK := stops[calls[i].stopID]
+				if !aOK || !bOK {
+					return nil, fmt.Errorf("trip %s: %w: %s", trip["trip_id"], ErrUnknownStation, calls[i].stopID)
+				}
+				if !a.hasCoord || !b.hasCoord {
+					return nil, fmt.Errorf("trip %s: stop %s has no coordinates", trip["trip_id"], calls[i].stopID)
+				}
+				distance += haversine(a.lat, a.lon, b.lat, b.lon)
+			}
+		}
+
+		first, last := stops[calls[0].stopID], stops[calls[len(calls)-1].stopID]
+		r := Route{
+			StartStation: first.name,
+			EndStation:   last.name,
+			NumStops:     len(calls) - 1,
+			Distance:     math.Round(distance*100) / 100,
+		}
+		if r.StartStation == "" || r.EndStation == "" {
+			return nil, fmt.Errorf("trip %s: %w", trip["trip_id"], ErrUnknownStation)
+		}
+		if !opts.KeepDuplicates {
+			if seen[r] {
+				continue
+			}
+			seen[r] = true
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+func importGTFSFromPath(path string, opts GTFSOptions) ([]Route, error) {
+	feed, closer, err := openGTFS(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	routes, err := importGTFS(feed, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return routes, nil
+}
+
+func runImportGTFS(args []string) {
+	fs := flag.NewFlagSet("import-gtfs", flag.ExitOnError)
+	format := fs.String("format", "csv", "output format: "+strings.Join(outputFormats, ", "))
+	date := fs.String("date", "", "only import trips running on this day (YYYYMMDD)")
+	all := fs.Bool("all", false, "emit one route per trip instead of one per distinct route")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Println("Usage: go run *.go import-gtfs [flags] <feed.zip|feed directory>")
+		return
+	}
+	opts := GTFSOptions{KeepDuplicates: *all}
+	if *date != "" {
+		d, err := time.Parse("20060102", *date)
+		if err != nil {
+			fmt.Println("Error parsing -date:", err)
+			return
+		}
+		opts.Date = d
+	}
+	routes, err := importGTFSFromPath(fs.Arg(0), opts)
+	if err != nil {
+		fmt.Println("Error reading feed:", err)
+		return
+	}
+	if err := writeRoutes(os.Stdout, routes, *format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
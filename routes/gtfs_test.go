@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func gtfsFeed(files map[string]string) fstest.MapFS {
+	feed := fstest.MapFS{
+		"stops.txt": {Data: []byte("stop_id,stop_name,stop_lat,stop_lon\n" +
+			"S1,Central,50.45,30.52\nS2,Market,50.46,30.52\nS3,Airport,50.40,30.90\n")},
+		"routes.txt": {Data: []byte("route_id\nR1\n")},
+		"trips.txt":  {Data: []byte("route_id,service_id,trip_id\nR1,WK,t1\nR1,WE,t2\n")},
+		"stop_times.txt": {Data: []byte("trip_id,stop_id,stop_sequence\n" +
+			"t1,S2,2\nt1,S1,1\nt2,S1,1\nt2,S3,5\n")},
+	}
+	for name, data := range files {
+		feed[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	return feed
+}
+
+func gtfsEndpoints(routes []Route) []string {
+	var got []string
+	for _, r := range routes {
+		got = append(got, r.StartStation+" -> "+r.EndStation)
+	}
+	return got
+}
+
+func TestImportGTFSDate(t *testing.T) {
+	const calendar = "service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n" +
+		"WK,1,1,1,1,1,0,0,20260101,20261231\nWE,0,0,0,0,0,1,1,20260101,20261231\n"
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		files map[string]string
+		date  time.Time
+		want  []string
+	}{
+		{"no date", nil, time.Time{}, []string{"Central -> Market", "Central -> Airport"}},
+		{"calendar", map[string]string{"calendar.txt": calendar}, monday, []string{"Central -> Market"}},
+		{
+			"calendar with exceptions",
+			map[string]string{
+				"calendar.txt":       calendar,
+				"calendar_dates.txt": "service_id,date,exception_type\nWK,20261019,2\nWE,20261019,1\n",
+			},
+			monday,
+			[]string{"Central -> Airport"},
+		},
+		{
+			"calendar_dates only",
+			map[string]string{"calendar_dates.txt": "service_id,date,exception_type\nWE,20261019,1\nWK,20261020,1\n"},
+			monday,
+			[]string{"Central -> Airport"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := importGTFS(gtfsFeed(tt.files), GTFSOptions{Date: tt.date})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := gtfsEndpoints(routes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("routes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportGTFSDateWithoutCalendar(t *testing.T) {
+	_, err := importGTFS(gtfsFeed(nil), GTFSOptions{Date: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)})
+	if !errors.Is(err, ErrMissingGTFSFile) {
+		t.Errorf("err = %v, want ErrMissingGTFSFile", err)
+	}
+}
+
+func TestImportGTFSBadStopSequence(t *testing.T) {
+	feed := gtfsFeed(map[string]string{"stop_times.txt": "trip_id,stop_id,stop_sequence\nt1,S1,x\n"})
+	if _, err := importGTFS(feed, GTFSOptions{}); !errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("err = %v, want ErrInvalidNumber", err)
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"container/heap"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// RouteIndex answers lookups on a fixed set of routes without scanning
+// all of them: positions by start and by end station, and all positions
+// ordered by distance for range and top-k queries.
+type RouteIndex struct {
+	routes     []Route
+	byStart    map[string][]int
+	byEnd      map[string][]int
+	byDistance []int
+}
+
+func newRouteIndex(routes []Route) *RouteIndex {
+	ix := &RouteIndex{
+		routes:     routes,
+		byStart:    make(map[string][]int),
+		byEnd:      make(map[string][]int),
+		byDistance: make([]int, len(routes)),
+	}
+	for i, r := range routes {
+		ix.byStart[r.StartStation] = append(ix.byStart[r.StartStation], i)
+		ix.byEnd[r.EndStation] = append(ix.byEnd[r.EndStation], i)
+		ix.byDistance[i] = i
+	}
+	sort.SliceStable(ix.byDistance, func(a, b int) bool {
+		return routes[ix.byDistance[a]].Distance < routes[ix.byDistance[b]].Distance
+	})
+	return ix
+}
+
+// Routes returns the indexed routes in their original order.
+func (ix *RouteIndex) Routes() []Route {
+	return ix.routes
+}
+
+func (ix *RouteIndex) collect(positions []int) []Route {
+	result := make([]Route, len(positions))
+	for i, p := range positions {
+		result[i] = ix.routes[p]
+	}
+	return result
+}
+
+func (ix *RouteIndex) ByStart(station string) []Route {
+	return ix.collect(ix.byStart[station])
+}
+
+func (ix *RouteIndex) ByEnd(station string) []Route {
+	return ix.collect(ix.byEnd[station])
+}
+
+// distanceRange returns the positions of routes with lo <= distance <= hi
+// in distance order.
+func (ix *RouteIndex) distanceRange(lo, hi float64) []int {
+	from := sort.Search(len(ix.byDistance), func(i int) bool { return ix.routes[ix.byDistance[i]].Distance >= lo })
+	to := sort.Search(len(ix.byDistance), func(i int) bool { return ix.routes[ix.byDistance[i]].Distance > hi })
+	if to < from {
+		return nil
+	}
+	return ix.byDistance[from:to]
+}
+
+func (ix *RouteIndex) DistanceBetween(lo, hi float64) []Route {
+	return ix.collect(ix.distanceRange(lo, hi))
+}
+
+// Shortest returns the k shortest routes, or the k longest with longest set.
+func (ix *RouteIndex) Shortest(k int, longest bool) []Route {
+	k = max(0, min(k, len(ix.byDistance)))
+	if !longest {
+		return ix.collect(ix.byDistance[:k])
+	}
+	positions := slices.Clone(ix.byDistance[len(ix.byDistance)-k:])
+	slices.Reverse(positions)
+	return ix.collect(positions)
+}
+
+// candidates returns the sorted positions of routes that can match e, or
+// ok=false when the index cannot narrow e down. Only equality on start or
+// end and comparisons on distance use the index; for and the smaller
+// candidate set is used, for or both sets are merged.
+func (ix *RouteIndex) candidates(e Expr) (positions []int, ok bool) {
+	if lo, hi, ok := distanceBounds(e); ok {
+		positions = slices.Clone(ix.distanceRange(lo, hi))
+		slices.Sort(positions)
+		return positions, true
+	}
+	switch e := e.(type) {
+	case comparison:
+		switch {
+		case e.field == "start" && e.op == "=":
+			return ix.byStart[e.str], true
+		case e.field == "end" && e.op == "=":
+			return ix.byEnd[e.str], true
+		}
+	case andExpr:
+		left, leftOK := ix.candidates(e.left)
+		right, rightOK := ix.candidates(e.right)
+		switch {
+		case leftOK && rightOK && len(right) < len(left):
+			return right, true
+		case leftOK:
+			return left, true
+		case rightOK:
+			return right, true
+		}
+	case orExpr:
+		left, leftOK := ix.candidates(e.left)
+		right, rightOK := ix.candidates(e.right)
+		if leftOK && rightOK {
+			merged := append(slices.Clone(left), right...)
+			slices.Sort(merged)
+			return slices.Compact(merged), true
+		}
+	}
+	return nil, false
+}
+
+// distanceBounds returns the closed distance interval containing every
+// route matching e, for comparisons on distance and conjunctions of them.
+func distanceBounds(e Expr) (lo, hi float64, ok bool) {
+	switch e := e.(type) {
+	case comparison:
+		if e.field != "distance" {
+			return 0, 0, false
+		}
+		switch e.op {
+		case "=":
+			return e.number, e.number, true
+		case "<", "<=":
+			return math.Inf(-1), e.number, true
+		case ">", ">=":
+			return e.number, math.Inf(1), true
+		}
+	case andExpr:
+		leftLo, leftHi, leftOK := distanceBounds(e.left)
+		rightLo, rightHi, rightOK := distanceBounds(e.right)
+		if leftOK && rightOK {
+			return math.Max(leftLo, rightLo), math.Min(leftHi, rightHi), true
+		}
+	}
+	return 0, 0, false
+}
+
+// Apply gives the same result as q.Apply(routes) but only evaluates the
+// routes the index selects for q.Where. Without a filter, ordering by
+// distance ascending reads the distance order directly.
+func (ix *RouteIndex) Apply(q *Query) []Route {
+	if q.Where == nil && len(q.OrderBy) == 1 && q.OrderBy[0] == (OrderKey{Field: "distance"}) {
+		if q.HasLimit {
+			return ix.Shortest(q.Limit, false)
+		}
+		return ix.Shortest(len(ix.routes), false)
+	}
+	if q.Where == nil {
+		return q.Apply(ix.routes)
+	}
+	positions, ok := ix.candidates(q.Where)
+	if !ok {
+		return q.Apply(ix.routes)
+	}
+	return q.Apply(ix.collect(positions))
+}
+
+// routeHeap is a bounded heap keeping the k best routes seen so far, with
+// the worst of them on top.
+type routeHeap struct {
+	routes []Route
+	better func(a, b Route) bool
+}
+
+func (h *routeHeap) Len() int           { return len(h.routes) }
+func (h *routeHeap) Less(i, j int) bool { return h.better(h.routes[j], h.routes[i]) }
+func (h *routeHeap) Swap(i, j int)      { h.routes[i], h.routes[j] = h.routes[j], h.routes[i] }
+func (h *routeHeap) Push(x any)         { h.routes = append(h.routes, x.(Route)) }
+func (h *routeHeap) Pop() any {
+	last := h.routes[len(h.routes)-1]
+	h.routes = h.routes[:len(h.routes)-1]
+	return last
+}
+
+// topK returns the k best routes by any field in O(n log k), best first.
+// Routes stream in through the returned add function; result sorts them.
+func topK(k int, field string, desc bool) (add func(Route), result func() []Route) {
+	better := func(a, b Route) bool {
+		c := compareField(a, b, field)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	h := &routeHeap{better: better}
+	add = func(r Route) {
+		if k <= 0 {
+			return
+		}
+		if h.Len() < k {
+			heap.Push(h, r)
+		} else if better(r, h.routes[0]) {
+			h.routes[0] = r
+			heap.Fix(h, 0)
+		}
+	}
+	result = func() []Route {
+		sorted := slices.Clone(h.routes)
+		sort.SliceStable(sorted, func(i, j int) bool { return better(sorted[i], sorted[j]) })
+		return sorted
+	}
+	return add, result
+}
+
+// generateRoutes makes n random routes between the given number of stations
+// for benchmarking.
+func generateRoutes(rng *rand.Rand, n, stations int) []Route {
+	routes := make([]Route, n)
+	for i := range routes {
+		routes[i] = Route{
+			StartStation: "Station " + strconv.Itoa(rng.Intn(stations)),
+			EndStation:   "Station " + strconv.Itoa(rng.Intn(stations)),
+			NumStops:     1 + rng.Intn(30),
+			Distance:     math.Round(rng.Float64()*1000) / 10,
+		}
+	}
+	return routes
+}
+
+type indexBenchResult struct {
+	Name        string `json:"name"`
+	Routes      int    `json:"routes"`
+	Nanoseconds int64  `json:"ns"`
+	Allocs      uint64 `json:"allocs"`
+	Bytes       uint64 `json:"bytes"`
+	Results     int    `json:"results"`
+}
+
+// benchOp runs op reps times and keeps the fastest run; allocations are
+// those of that run.
+func benchOp(name string, routes, reps int, op func() int) indexBenchResult {
+	best := indexBenchResult{Name: name, Routes: routes, Nanoseconds: math.MaxInt64}
+	for range max(reps, 1) {
+		runtime.GC()
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		n := op()
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		if elapsed.Nanoseconds() < best.Nanoseconds {
+			best.Nanoseconds = elapsed.Nanoseconds()
+			best.Allocs = after.Mallocs - before.Mallocs
+			best.Bytes = after.TotalAlloc - before.TotalAlloc
+			best.Results = n
+		}
+	}
+	return best
+}
+
+func runIndexBench(n, stations, reps, k int, seed int64) ([]indexBenchResult, error) {
+	routes := generateRoutes(rand.New(rand.NewSource(seed)), n, stations)
+	var data bytes.Buffer
+	if err := writeRoutes(&data, routes, "csv"); err != nil {
+		return nil, err
+	}
+	station := routes[0].StartStation
+	byStart := &Query{Where: comparison{field: "start", op: "=", str: station}}
+	rangeQuery, err := parseQuery("distance >= 10 and distance < 11")
+	if err != nil {
+		return nil, err
+	}
+
+	var results []indexBenchResult
+	add := func(name string, op func() int) {
+		results = append(results, benchOp(name, n, reps, op))
+	}
+	add("read-all", func() int {
+		rs, _, _ := readRoutes(bytes.NewReader(data.Bytes()), ReadOptions{})
+		return len(rs)
+	})
+	add("stream-count", func() int {
+		count := 0
+		streamRoutes(bytes.NewReader(data.Bytes()), ReadOptions{}, func(Route) error {
+			count++
+			return nil
+		})
+		return count
+	})
+
+	var ix *RouteIndex
+	add("index-build", func() int {
+		ix = newRouteIndex(routes)
+		return len(ix.byStart)
+	})
+	add("filter-start-scan", func() int { return len(byStart.Apply(routes)) })
+	add("filter-start-index", func() int { return len(ix.Apply(byStart)) })
+	add("distance-range-scan", func() int { return len(rangeQuery.Apply(routes)) })
+	add("distance-range-index", func() int { return len(ix.Apply(rangeQuery)) })
+	add("top-k-sort", func() int {
+		sorted := slices.Clone(routes)
+		sortRoutesByDistance(sorted)
+		return len(sorted[:min(k, len(sorted))])
+	})
+	add("top-k-heap", func() int {
+		push, result := topK(k, "distance", false)
+		for _, r := range routes {
+			push(r)
+		}
+		return len(result())
+	})
+	add("top-k-index", func() int { return len(ix.Shortest(k, false)) })
+	return results, nil
+}
+
+func writeIndexBench(w io.Writer, results []indexBenchResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BENCHMARK\tROUTES\tTIME\tALLOCS\tBYTES\tRESULTS")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%d\t%d\n", r.Name, r.Routes, time.Duration(r.Nanoseconds), r.Allocs, r.Bytes, r.Results)
+	}
+	return tw.Flush()
+}
+
+// runTop prints the k best routes by a field, streaming CSV files through
+// a bounded heap instead of loading them.
+func runTop(args []string) {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	k := fs.Int("k", 10, "number of routes")
+	by := fs.String("by", "distance", "field to rank by, with optional :desc")
+	fs.Parse(args)
+
+	keys, err := parseOrderKeys(*by)
+	if err != nil || len(keys) != 1 {
+		fmt.Println("-by expects a single field such as distance or stops:desc")
+		return
+	}
+	if err := checkFormat(*opts.format); err != nil {
+		fmt.Println(err)
+		return
+	}
+	delim, err := parseDelimiter(*opts.delim)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	push, result := topK(*k, keys[0].Field, keys[0].Desc)
+	err = eachRoute(*opts.file, ReadOptions{Delimiter: delim, Lenient: *opts.lenient}, func(r Route) error {
+		push(r)
+		return nil
+	})
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	if err := writeRoutes(os.Stdout, result(), *opts.format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
+
+func runBenchIndex(args []string) {
+	fs := flag.NewFlagSet("bench-index", flag.ExitOnError)
+	n := fs.Int("n", 1000000, "number of generated routes")
+	stations := fs.Int("stations", 1000, "number of distinct stations")
+	reps := fs.Int("reps", 3, "runs of each benchmark; the fastest is reported")
+	k := fs.Int("k", 10, "k for top-k benchmarks")
+	seed := fs.Int64("seed", 1, "random seed for generated routes")
+	format := fs.String("format", "table", "report format: table or json")
+	fs.Parse(args)
+
+	if *n < 1 {
+		fmt.Println("-n must be at least 1")
+		return
+	}
+	results, err := runIndexBench(*n, max(*stations, 1), *reps, *k, *seed)
+	if err != nil {
+		fmt.Println("Error running benchmarks:", err)
+		return
+	}
+	if *format == "json" {
+		err = writeJSON(os.Stdout, results)
+	} else {
+		err = writeIndexBench(os.Stdout, results)
+	}
+	if err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"math/rand"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRouteIndexApply(t *testing.T) {
+	routes := generateRoutes(rand.New(rand.NewSource(1)), 2000, 20)
+	ix := newRouteIndex(routes)
+	for _, text := range []string{
+		`start = "Station 3"`,
+		`end = "Station 7" order by distance desc limit 5`,
+		`distance >= 10 and distance < 20`,
+		`distance = 42.5`,
+		`start = "Station 3" and distance > 50`,
+		`distance < 30 and start = "Station 3" and end = "Station 4"`,
+		`start = "Station 1" or end = "Station 2"`,
+		`start = "Station 1" or distance < 5`,
+		`start = "Station 1" or stops > 25`,
+		`(start = "Station 1" or start = "Station 2") and not end = "Station 3"`,
+		`not start = "Station 1"`,
+		`not (distance < 50 or start = "Station 1")`,
+		`start = "Station 5" and not (distance > 10 and distance < 90)`,
+		`distance > 90 and distance < 10`,
+		`start = "Nowhere"`,
+		`order by distance`,
+		`order by distance limit 7`,
+		`order by distance limit 0`,
+		`order by distance desc limit 3`,
+		`order by stops, distance desc`,
+	} {
+		q, err := parseQuery(text)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		got, want := ix.Apply(q), q.Apply(routes)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: index returned %d routes, scan %d, or a different order", text, len(got), len(want))
+		}
+	}
+}
+
+func TestEachRoute(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "routes.csv")
+	writeTestFile(t, csvPath, "A,B,3,10\nA,C,x,5\nB,C,2,4\n")
+	store, dbPath := newTestStore(t, storeTestRoutes...)
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		file string
+		opts ReadOptions
+		want int
+	}{
+		{csvPath, ReadOptions{Lenient: true}, 2},
+		{dbPath, ReadOptions{}, len(storeTestRoutes)},
+	} {
+		count := 0
+		err := eachRoute(tt.file, tt.opts, func(Route) error {
+			count++
+			return nil
+		})
+		if err != nil || count != tt.want {
+			t.Errorf("%s: %d routes, %v; want %d", filepath.Base(tt.file), count, err, tt.want)
+		}
+	}
+	if err := eachRoute(csvPath, ReadOptions{}, func(Route) error { return nil }); err == nil {
+		t.Error("strict read of an invalid row succeeded")
+	}
+}
+
+const (
+	benchRoutes   = 100000
+	benchStations = 1000
+)
+
+func benchIndex(b *testing.B) ([]Route, *RouteIndex) {
+	b.Helper()
+	routes := generateRoutes(rand.New(rand.NewSource(1)), benchRoutes, benchStations)
+	return routes, newRouteIndex(routes)
+}
+
+func benchQuery(b *testing.B, text string) *Query {
+	b.Helper()
+	q, err := parseQuery(text)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return q
+}
+
+func BenchmarkFilterStart(b *testing.B) {
+	routes, ix := benchIndex(b)
+	q := benchQuery(b, `start = "`+routes[0].StartStation+`"`)
+	b.Run("scan", func(b *testing.B) {
+		for range b.N {
+			q.Apply(routes)
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		for range b.N {
+			ix.Apply(q)
+		}
+	})
+}
+
+func BenchmarkDistanceRange(b *testing.B) {
+	routes, ix := benchIndex(b)
+	q := benchQuery(b, "distance >= 10 and distance < 11")
+	b.Run("scan", func(b *testing.B) {
+		for range b.N {
+			q.Apply(routes)
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		for range b.N {
+			ix.Apply(q)
+		}
+	})
+}
+
+func BenchmarkTopK(b *testing.B) {
+	const k = 10
+	routes, ix := benchIndex(b)
+	b.Run("sort", func(b *testing.B) {
+		for range b.N {
+			sorted := slices.Clone(routes)
+			sortRoutesByDistance(sorted)
+			_ = sorted[:k]
+		}
+	})
+	b.Run("heap", func(b *testing.B) {
+		for range b.N {
+			push, result := topK(k, "distance", false)
+			for _, r := range routes {
+				push(r)
+			}
+			result()
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		for range b.N {
+			ix.Shortest(k, false)
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"container/heap"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// JourneyConstraints limit the itineraries considered by planJourneys.
+// A negative value means the limit is not applied.
+type JourneyConstraints struct {
+	MaxTransfers int
+	MaxStops     int
+	MaxDistance  float64
+}
+
+func (c JourneyConstraints) allows(p Path) bool {
+	if c.MaxTransfers >= 0 && len(p.Legs)-1 > c.MaxTransfers {
+		return false
+	}
+	if c.MaxStops >= 0 && p.NumStops > c.MaxStops {
+		return false
+	}
+	if c.MaxDistance >= 0 && p.Distance > c.MaxDistance {
+		return false
+	}
+	return true
+}
+
+// beyond reports whether p breaks the limit on the quantity objective
+// ranks by. Itineraries come out of planJourneys' search in objective
+// order, so every later one breaks that limit too.
+func (c JourneyConstraints) beyond(objective string, p Path) bool {
+	switch objective {
+	case "distance":
+		return c.MaxDistance >= 0 && p.Distance > c.MaxDistance
+	case "stops":
+		return c.MaxStops >= 0 && p.NumStops > c.MaxStops
+	case "transfers":
+		return c.MaxTransfers >= 0 && len(p.Legs)-1 > c.MaxTransfers
+	}
+	return false
+}
+
+// journeyObjective ranks itineraries: score is the cost of a whole
+// itinerary and leg is what each leg adds to it.
+type journeyObjective struct {
+	score func(Path) float64
+	leg   WeightFunc
+}
+
+var journeyObjectives = map[string]journeyObjective{
+	"distance":  {func(p Path) float64 { return p.Distance }, weightByDistance},
+	"stops":     {func(p Path) float64 { return float64(p.NumStops) }, weightByStops},
+	"transfers": {func(p Path) float64 { return float64(len(p.Legs) - 1) }, func(Route) float64 { return 1 }},
+}
+
+// journeyLeg identifies an edge of the station graph as the i-th edge
+// leaving from, so parallel routes between two stations stay distinct.
+type journeyLeg struct {
+	from string
+	i    int
+}
+
+type journey struct {
+	legs []journeyLeg
+	path Path
+}
+
+func (g *StationGraph) journey(from string, legs []journeyLeg, score func(Path) float64) journey {
+	p := Path{Stations: []string{from}}
+	for _, l := range legs {
+		e := g.adjList[l.from][l.i]
+		p.Stations = append(p.Stations, e.To)
+		p.Legs = append(p.Legs, e.Route)
+		p.Distance += e.Route.Distance
+		p.NumStops += e.Route.NumStops
+	}
+	p.Cost = score(p)
+	return journey{legs: legs, path: p}
+}
+
+// cheaperJourney orders itineraries by cost, then distance.
+func cheaperJourney(a, b Path) bool {
+	if a.Cost != b.Cost {
+		return a.Cost < b.Cost
+	}
+	return a.Distance < b.Distance
+}
+
+type journeyItem struct {
+	station        string
+	cost, distance float64
+}
+
+func (a journeyItem) cheaper(b journeyItem) bool {
+	if a.cost != b.cost {
+		return a.cost < b.cost
+	}
+	return a.distance < b.distance
+}
+
+type journeyQueue []journeyItem
+
+func (q journeyQueue) Len() int           { return len(q) }
+func (q journeyQueue) Less(i, j int) bool { return q[i].cheaper(q[j]) }
+func (q journeyQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *journeyQueue) Push(x any)        { *q = append(*q, x.(journeyItem)) }
+func (q *journeyQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// cheapestJourney is Dijkstra from one station to another by leg weight,
+// then distance, avoiding the banned legs and stations.
+func (g *StationGraph) cheapestJourney(from, to string, weight WeightFunc, bannedLegs map[journeyLeg]bool, bannedStations map[string]bool) ([]journeyLeg, bool) {
+	best := map[string]journeyItem{from: {from, 0, 0}}
+	prev := make(map[string]journeyLeg)
+	queue := &journeyQueue{best[from]}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(journeyItem)
+		if item != best[item.station] {
+			continue
+		}
+		if item.station == to {
+			var legs []journeyLeg
+			for at := to; at != from; at = prev[at].from {
+				legs = append(legs, prev[at])
+			}
+			slices.Reverse(legs)
+			return legs, true
+		}
+		for i, e := range g.adjList[item.station] {
+			leg := journeyLeg{item.station, i}
+			if bannedLegs[leg] || bannedStations[e.To] {
+				continue
+			}
+			next := journeyItem{e.To, item.cost + weight(e.Route), item.distance + e.Route.Distance}
+			if old, ok := best[e.To]; !ok || next.cheaper(old) {
+				best[e.To] = next
+				prev[e.To] = leg
+				heap.Push(queue, next)
+			}
+		}
+	}
+	return nil, false
+}
+
+// planJourneys returns up to k itineraries from one station to another that
+// satisfy the constraints, best first by the named objective. Itineraries
+// never visit a station twice.
+//
+// Loopless itineraries are generated cheapest first with Yen's algorithm
+// and the ones breaking a constraint are skipped, so the result is exactly
+// the k best valid itineraries.
+func planJourneys(routes []Route, from, to string, c JourneyConstraints, objective string, k int) ([]Path, error) {
+	obj, ok := journeyObjectives[objective]
+	if !ok {
+		return nil, fmt.Errorf("unknown objective %q, expected distance, stops or transfers", objective)
+	}
+	g := buildStationGraph(routes, weightByDistance, false)
+	if err := g.checkStations(from, to); err != nil {
+		return nil, err
+	}
+	if k < 1 {
+		k = 1
+	}
+	noPath := fmt.Errorf("%w: %s -> %s", ErrNoPath, from, to)
+	if from == to {
+		return nil, noPath
+	}
+
+	first, ok := g.cheapestJourney(from, to, obj.leg, nil, nil)
+	if !ok {
+		return nil, noPath
+	}
+	found := []journey{g.journey(from, first, obj.score)}
+	seen := map[string]bool{fmt.Sprint(first): true}
+	var candidates []journey
+	var best []Path
+	for {
+		last := found[len(found)-1]
+		if c.allows(last.path) {
+			best = append(best, last.path)
+			if len(best) == k {
+				break
+			}
+		} else if c.beyond(objective, last.path) {
+			break
+		}
+
+		// Every itinerary after last deviates from one of the found ones
+		// at some station; try each station of last as that spur.
+		for i := range last.legs {
+			root := last.legs[:i]
+			rootPath := g.journey(from, root, obj.score).path
+			if !c.allows(rootPath) {
+				break
+			}
+			bannedLegs := make(map[journeyLeg]bool)
+			for _, f := range found {
+				if len(f.legs) > i && slices.Equal(f.legs[:i], root) {
+					bannedLegs[f.legs[i]] = true
+				}
+			}
+			bannedStations := make(map[string]bool)
+			for _, station := range rootPath.Stations[:i] {
+				bannedStations[station] = true
+			}
+			spur, ok := g.cheapestJourney(rootPath.Stations[i], to, obj.leg, bannedLegs, bannedStations)
+			if !ok {
+				continue
+			}
+			legs := append(slices.Clone(root), spur...)
+			if key := fmt.Sprint(legs); !seen[key] {
+				seen[key] = true
+				candidates = append(candidates, g.journey(from, legs, obj.score))
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		next := 0
+		for i := range candidates {
+			if cheaperJourney(candidates[i].path, candidates[next].path) {
+				next = i
+			}
+		}
+		found = append(found, candidates[next])
+		candidates = slices.Delete(candidates, next, next+1)
+	}
+
+	if len(best) == 0 {
+		return nil, noPath
+	}
+	return best, nil
+}
+
+func runJourney(args []string) {
+	fs := flag.NewFlagSet("journey", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	maxTransfers := fs.Int("max-transfers", -1, "maximum number of transfers (-1 for no limit)")
+	maxStops := fs.Int("max-stops", -1, "maximum total stops (-1 for no limit)")
+	maxDistance := fs.Float64("max-distance", -1, "maximum total distance in km (-1 for no limit)")
+	objective := fs.String("by", "distance", "ranking objective: distance, stops or transfers")
+	k := fs.Int("k", 3, "number of alternatives to return")
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fmt.Println("Usage: go run *.go journey [flags] <from> <to>")
+		return
+	}
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+
+	c := JourneyConstraints{MaxTransfers: *maxTransfers, MaxStops: *maxStops, MaxDistance: *maxDistance}
+	journeys, err := planJourneys(routes, fs.Arg(0), fs.Arg(1), c, *objective, *k)
+	if err != nil {
+		fmt.Println("Error planning journey:", err)
+		return
+	}
+	if err := writePaths(os.Stdout, journeys, *opts.format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
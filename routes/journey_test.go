@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestPlanJourneys(t *testing.T) {
+	routes := []Route{
+		{"A", "B", 3, 10.5},
+		{"C", "D", 4, 15},
+		{"A", "E", 5, 25},
+		{"B", "C", 2, 8},
+		{"A", "C", 6, 20},
+		{"E", "D", 1, 5},
+		{"B", "D", 9, 30},
+	}
+	tests := []struct {
+		objective string
+		c         JourneyConstraints
+		k         int
+		want      [][]string
+	}{
+		{"distance", JourneyConstraints{-1, -1, -1}, 5, [][]string{
+			{"A", "E", "D"}, {"A", "B", "C", "D"}, {"A", "C", "D"}, {"A", "B", "D"},
+		}},
+		{"transfers", JourneyConstraints{-1, -1, -1}, 2, [][]string{
+			{"A", "E", "D"}, {"A", "C", "D"},
+		}},
+		{"distance", JourneyConstraints{1, -1, 36}, 3, [][]string{
+			{"A", "E", "D"}, {"A", "C", "D"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%+v/k=%d", tt.objective, tt.c, tt.k), func(t *testing.T) {
+			paths, err := planJourneys(routes, "A", "D", tt.c, tt.objective, tt.k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][]string
+			for _, p := range paths {
+				got = append(got, p.Stations)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("journeys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanJourneysConstraints(t *testing.T) {
+	// The three cheapest ways to X all need a transfer at a B station.
+	branches := []Route{
+		{"A", "B1", 1, 1}, {"A", "B2", 1, 1}, {"A", "B3", 1, 1},
+		{"B1", "X", 1, 1}, {"B2", "X", 1, 1}, {"B3", "X", 1, 1},
+		{"A", "X", 1, 5}, {"X", "D", 1, 1},
+	}
+	single := []Route{{"A", "B", 1, 1}, {"B", "X", 1, 1}, {"A", "X", 1, 5}, {"X", "D", 1, 1}}
+	stops := []Route{{"A", "B", 1, 1}, {"B", "D", 1, 1}, {"A", "D", 9, 3}, {"A", "C", 2, 2}, {"C", "D", 2, 2}}
+	none := JourneyConstraints{-1, -1, -1}
+	tests := []struct {
+		name      string
+		routes    []Route
+		objective string
+		c         JourneyConstraints
+		k         int
+		want      [][]string
+	}{
+		{"max transfers, k=3", branches, "distance", JourneyConstraints{1, -1, -1}, 3, [][]string{{"A", "X", "D"}}},
+		{"max transfers, k=1", single, "distance", JourneyConstraints{1, -1, -1}, 1, [][]string{{"A", "X", "D"}}},
+		{"no transfers", single, "distance", JourneyConstraints{0, -1, -1}, 1, nil},
+		{"max stops", stops, "distance", JourneyConstraints{-1, 3, -1}, 3, [][]string{{"A", "B", "D"}}},
+		{"max stops by stops", stops, "stops", JourneyConstraints{-1, 4, -1}, 3, [][]string{{"A", "B", "D"}, {"A", "C", "D"}}},
+		{"max distance", stops, "stops", JourneyConstraints{-1, -1, 3}, 3, [][]string{{"A", "B", "D"}, {"A", "D"}}},
+		{"max distance by distance", stops, "distance", JourneyConstraints{-1, -1, 2.5}, 3, [][]string{{"A", "B", "D"}}},
+		{"all constraints", branches, "stops", JourneyConstraints{2, 3, 4}, 5, [][]string{
+			{"A", "B1", "X", "D"}, {"A", "B2", "X", "D"}, {"A", "B3", "X", "D"},
+		}},
+		{"parallel routes", []Route{{"A", "B", 1, 2}, {"A", "B", 2, 1}}, "distance", none, 3, [][]string{{"A", "B"}, {"A", "B"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := planJourneys(tt.routes, "A", tt.routes[len(tt.routes)-1].EndStation, tt.c, tt.objective, tt.k)
+			if tt.want == nil {
+				if !errors.Is(err, ErrNoPath) {
+					t.Fatalf("err = %v, want ErrNoPath", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][]string
+			for _, p := range paths {
+				if !tt.c.allows(p) {
+					t.Errorf("journey %v breaks the constraints", p.Stations)
+				}
+				got = append(got, p.Stations)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("journeys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// allJourneys lists every loopless itinerary, for comparison with
+// planJourneys on small graphs.
+func allJourneys(routes []Route, from, to string) []Path {
+	g := buildStationGraph(routes, weightByDistance, false)
+	var result []Path
+	var walk func(p Path)
+	walk = func(p Path) {
+		at := p.Stations[len(p.Stations)-1]
+		if at == to && len(p.Legs) > 0 {
+			result = append(result, p)
+			return
+		}
+		for _, e := range g.adjList[at] {
+			if slices.Contains(p.Stations, e.To) {
+				continue
+			}
+			walk(Path{
+				Stations: append(slices.Clone(p.Stations), e.To),
+				Legs:     append(slices.Clone(p.Legs), e.Route),
+				Distance: p.Distance + e.Route.Distance,
+				NumStops: p.NumStops + e.Route.NumStops,
+			})
+		}
+	}
+	walk(Path{Stations: []string{from}})
+	return result
+}
+
+func TestPlanJourneysMatchesExhaustiveSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := range 200 {
+		routes := make([]Route, 6+rng.Intn(10))
+		for i := range routes {
+			routes[i] = Route{
+				StartStation: fmt.Sprint(rng.Intn(6)),
+				EndStation:   fmt.Sprint(rng.Intn(6)),
+				NumStops:     1 + rng.Intn(4),
+				Distance:     float64(1 + rng.Intn(9)),
+			}
+		}
+		routes = append(routes, Route{"0", "x", 1, 1}, Route{"y", "5", 1, 1})
+		c := JourneyConstraints{rng.Intn(5) - 1, rng.Intn(12) - 1, float64(rng.Intn(25) - 1)}
+		objective := []string{"distance", "stops", "transfers"}[rng.Intn(3)]
+		k := 1 + rng.Intn(4)
+
+		score := journeyObjectives[objective].score
+		var want [][2]float64
+		for _, p := range allJourneys(routes, "0", "5") {
+			if c.allows(p) {
+				want = append(want, [2]float64{score(p), p.Distance})
+			}
+		}
+		sort.Slice(want, func(i, j int) bool {
+			return want[i][0] < want[j][0] || want[i][0] == want[j][0] && want[i][1] < want[j][1]
+		})
+		want = want[:min(k, len(want))]
+
+		paths, err := planJourneys(routes, "0", "5", c, objective, k)
+		if len(want) == 0 {
+			if !errors.Is(err, ErrNoPath) {
+				t.Fatalf("round %d: err = %v, want ErrNoPath", round, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+		var got [][2]float64
+		for _, p := range paths {
+			got = append(got, [2]float64{p.Cost, p.Distance})
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("round %d (%s, %+v, k=%d): got %v, want %v\nroutes %v", round, objective, c, k, got, want, routes)
+		}
+	}
+}
+
+// TestPlanJourneysDenseGraph would never finish with a search over every
+// simple path: a complete graph of 40 stations has more than 38! of them.
+func TestPlanJourneysDenseGraph(t *testing.T) {
+	const n = 40
+	var routes []Route
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i != j {
+				routes = append(routes, Route{fmt.Sprint(i), fmt.Sprint(j), 1, float64(1 + (i*7+j*13)%17)})
+			}
+		}
+	}
+	paths, err := planJourneys(routes, "0", fmt.Sprint(n-1), JourneyConstraints{-1, -1, -1}, "distance", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 10 {
+		t.Fatalf("got %d journeys, want 10", len(paths))
+	}
+	for i, p := range paths {
+		if i > 0 && p.Cost < paths[i-1].Cost {
+			t.Errorf("journey %d costs %g, less than the one before", i, p.Cost)
+		}
+		seen := slices.Clone(p.Stations)
+		slices.Sort(seen)
+		if len(slices.Compact(seen)) != len(p.Stations) {
+			t.Errorf("journey %v visits a station twice", p.Stations)
+		}
+	}
+}
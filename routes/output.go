@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+var outputFormats = []string{"table", "csv", "json", "ndjson"}
+
+func checkFormat(format string) error {
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, expected one of %v", format, outputFormats)
+}
+
+func formatDistance(d float64) string {
+	return strconv.FormatFloat(d, 'f', -1, 64)
+}
+
+// writeRoutes prints routes as an aligned table, CSV with a header row,
+// a JSON array or newline-delimited JSON.
+func writeRoutes(w io.Writer, routes []Route, format string) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "START\tEND\tSTOPS\tDISTANCE")
+		for _, r := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\n", r.StartStation, r.EndStation, r.NumStops, r.Distance)
+		}
+		return tw.Flush()
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"start", "end", "stops", "distance"})
+		for _, r := range routes {
+			writer.Write([]string{r.StartStation, r.EndStation, strconv.Itoa(r.NumStops), formatDistance(r.Distance)})
+		}
+		writer.Flush()
+		return writer.Error()
+	case "json":
+		if routes == nil {
+			routes = []Route{}
+		}
+		return writeJSON(w, routes)
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		for _, r := range routes {
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return checkFormat(format)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
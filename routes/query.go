@@ -0,0 +1,410 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query is a parsed filter expression such as
+//
+//	start = "Station A" and distance < 20 order by stops desc limit 5
+//
+// Fields are start, end, stops, distance and avg (distance per stop).
+// Limit only applies when HasLimit is set, so "limit 0" returns no routes.
+type Query struct {
+	Where    Expr
+	OrderBy  []OrderKey
+	Limit    int
+	HasLimit bool
+}
+
+type OrderKey struct {
+	Field string
+	Desc  bool
+}
+
+// Expr is a boolean condition on a route.
+type Expr interface {
+	Eval(r Route) bool
+}
+
+type andExpr struct{ left, right Expr }
+type orExpr struct{ left, right Expr }
+type notExpr struct{ inner Expr }
+
+func (e andExpr) Eval(r Route) bool { return e.left.Eval(r) && e.right.Eval(r) }
+func (e orExpr) Eval(r Route) bool  { return e.left.Eval(r) || e.right.Eval(r) }
+func (e notExpr) Eval(r Route) bool { return !e.inner.Eval(r) }
+
+// comparison compares a route field with a literal. Numeric comparisons
+// against an undefined value (avg of a route without stops) are false.
+type comparison struct {
+	field  string
+	op     string
+	str    string
+	number float64
+}
+
+var stringFields = map[string]func(Route) string{
+	"start": func(r Route) string { return r.StartStation },
+	"end":   func(r Route) string { return r.EndStation },
+}
+
+var numberFields = map[string]func(Route) float64{
+	"stops":    func(r Route) float64 { return float64(r.NumStops) },
+	"distance": func(r Route) float64 { return r.Distance },
+	"avg":      avgStopLength,
+}
+
+func avgStopLength(r Route) float64 {
+	if r.NumStops <= 0 {
+		return math.NaN()
+	}
+	return r.Distance / float64(r.NumStops)
+}
+
+func (c comparison) Eval(r Route) bool {
+	if get, ok := stringFields[c.field]; ok {
+		v := get(r)
+		switch c.op {
+		case "=":
+			return v == c.str
+		case "!=":
+			return v != c.str
+		case "<":
+			return v < c.str
+		case "<=":
+			return v <= c.str
+		case ">":
+			return v > c.str
+		case ">=":
+			return v >= c.str
+		case "contains":
+			return strings.Contains(v, c.str)
+		}
+		return false
+	}
+
+	v := numberFields[c.field](r)
+	if math.IsNaN(v) {
+		return false
+	}
+	switch c.op {
+	case "=":
+		return v == c.number
+	case "!=":
+		return v != c.number
+	case "<":
+		return v < c.number
+	case "<=":
+		return v <= c.number
+	case ">":
+		return v > c.number
+	case ">=":
+		return v >= c.number
+	}
+	return false
+}
+
+// Apply filters, orders and limits routes without modifying the input.
+func (q *Query) Apply(routes []Route) []Route {
+	var result []Route
+	for _, r := range routes {
+		if q.Where == nil || q.Where.Eval(r) {
+			result = append(result, r)
+		}
+	}
+	if len(q.OrderBy) > 0 {
+		sort.SliceStable(result, func(i, j int) bool {
+			for _, key := range q.OrderBy {
+				c := compareField(result[i], result[j], key.Field)
+				if c == 0 {
+					continue
+				}
+				if key.Desc {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+	if q.HasLimit && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result
+}
+
+func compareField(a, b Route, field string) int {
+	if get, ok := stringFields[field]; ok {
+		return strings.Compare(get(a), get(b))
+	}
+	get := numberFields[field]
+	x, y := get(a), get(b)
+	switch {
+	case math.IsNaN(x) && math.IsNaN(y):
+		return 0
+	case math.IsNaN(x):
+		return 1
+	case math.IsNaN(y):
+		return -1
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func isField(name string) bool {
+	_, s := stringFields[name]
+	_, n := numberFields[name]
+	return s || n
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(s) {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("position %d: unterminated string", i+1)
+			}
+			tokens = append(tokens, token{tokString, s[i+1 : i+1+end], i})
+			i += end + 2
+		case strings.ContainsRune("=!<>", c):
+			op := string(c)
+			if i+1 < len(s) && s[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("position %d: expected != ", i+1)
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += len(op)
+		case unicode.IsDigit(c) || c == '-' || c == '.':
+			start := i
+			for i < len(s) && (unicode.IsDigit(rune(s[i])) || strings.ContainsRune("-+.eE", rune(s[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, s[start:i], start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(s) && (unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i])) || s[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, strings.ToLower(s[start:i]), start})
+		default:
+			return nil, fmt.Errorf("position %d: unexpected character %q", i+1, c)
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(s)}), nil
+}
+
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *queryParser) isKeyword(word string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && t.text == word
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("position %d: %s", p.peek().pos+1, fmt.Sprintf(format, args...))
+}
+
+// parseQuery parses the query language described on Query.
+func parseQuery(s string) (*Query, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	q := &Query{}
+
+	if !p.isKeyword("order") && !p.isKeyword("limit") && p.peek().kind != tokEOF {
+		q.Where, err = p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isKeyword("order") {
+		p.next()
+		if !p.isKeyword("by") {
+			return nil, p.errorf("expected 'by' after 'order'")
+		}
+		p.next()
+		for {
+			t := p.next()
+			if t.kind != tokIdent || !isField(t.text) {
+				return nil, fmt.Errorf("position %d: unknown field %q", t.pos+1, t.text)
+			}
+			key := OrderKey{Field: t.text}
+			if p.isKeyword("desc") {
+				key.Desc = true
+				p.next()
+			} else if p.isKeyword("asc") {
+				p.next()
+			}
+			q.OrderBy = append(q.OrderBy, key)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.isKeyword("limit") {
+		p.next()
+		t := p.next()
+		n, err := strconv.Atoi(t.text)
+		if t.kind != tokNumber || err != nil || n < 0 {
+			return nil, fmt.Errorf("position %d: limit expects a non-negative integer", t.pos+1)
+		}
+		q.Limit, q.HasLimit = n, true
+	}
+
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.peek().text)
+	}
+	return q, nil
+}
+
+func (p *queryParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (Expr, error) {
+	if p.isKeyword("not") {
+		p.next()
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (Expr, error) {
+	if p.peek().kind == tokLParen {
+		p.next()
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, p.errorf("expected )")
+		}
+		p.next()
+		return e, nil
+	}
+
+	field := p.next()
+	if field.kind != tokIdent || !isField(field.text) {
+		return nil, fmt.Errorf("position %d: unknown field %q", field.pos+1, field.text)
+	}
+	op := p.next()
+	if op.kind != tokOp && !(op.kind == tokIdent && op.text == "contains") {
+		return nil, fmt.Errorf("position %d: expected comparison operator after %s", op.pos+1, field.text)
+	}
+	value := p.next()
+
+	c := comparison{field: field.text, op: op.text}
+	if _, ok := stringFields[field.text]; ok {
+		if value.kind != tokString {
+			return nil, fmt.Errorf("position %d: %s expects a quoted string", value.pos+1, field.text)
+		}
+		c.str = value.text
+		return c, nil
+	}
+	if op.text == "contains" {
+		return nil, fmt.Errorf("position %d: contains only applies to start and end", op.pos+1)
+	}
+	n, err := strconv.ParseFloat(value.text, 64)
+	if value.kind != tokNumber || err != nil {
+		return nil, fmt.Errorf("position %d: %s expects a number", value.pos+1, field.text)
+	}
+	c.number = n
+	return c, nil
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// routeServer serves queries over a routes file, route store or GTFS feed
+// and reloads it when its size or modification time changes.
+type routeServer struct {
+	file string
+	opts ReadOptions
+
+	mu      sync.RWMutex
+	index   *RouteIndex
+	modTime time.Time
+	size    int64
+}
+
+func newRouteServer(file string, opts ReadOptions) (*routeServer, error) {
+	s := &routeServer{file: file, opts: opts}
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload rereads the file if it changed since the last load. A file that
+// fails to parse keeps the previous routes in service.
+func (s *routeServer) reload() (bool, error) {
+	modTime, size, err := sourceStamp(s.file)
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	unchanged := modTime.Equal(s.modTime) && size == s.size
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	routes, err := loadRoutes(s.file, s.opts)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	s.index, s.modTime, s.size = newRouteIndex(routes), modTime, size
+	s.mu.Unlock()
+	return true, nil
+}
+
+// sourceStamp returns the modification time and size of path. For a GTFS
+// feed stored as a directory these are the latest modification time and
+// the total size of its files, so editing any of them counts as a change.
+func sourceStamp(path string) (time.Time, int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if !info.IsDir() {
+		return info.ModTime(), info.Size(), nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	modTime, size := info.ModTime(), int64(0)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		size += info.Size()
+	}
+	return modTime, size, nil
+}
+
+func (s *routeServer) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := s.reload()
+			if err != nil {
+				log.Println("reload failed:", err)
+			} else if changed {
+				log.Printf("reloaded %s", s.file)
+			}
+		}
+	}
+}
+
+// snapshot returns the index of the routes currently in service; it is
+// replaced, never modified, by reload.
+func (s *routeServer) snapshot() *RouteIndex {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index
+}
+
+func (s *routeServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/routes", s.handleRoutes)
+	mux.HandleFunc("/routes/maxstops", s.handleMaxStops)
+	mux.HandleFunc("/routes/avg-below", s.handleAvgBelow)
+	mux.HandleFunc("/path", s.handlePath)
+	return mux
+}
+
+// httpError carries the status code for an error response.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string { return e.err.Error() }
+func (e *httpError) Unwrap() error { return e.err }
+
+func badRequest(err error) error {
+	return &httpError{http.StatusBadRequest, err}
+}
+
+// page is the envelope of every list response.
+type page struct {
+	Total  int     `json:"total"`
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+	Routes []Route `json:"routes"`
+}
+
+func paginate(r *http.Request, routes []Route) (page, error) {
+	p := page{Total: len(routes), Limit: defaultPageSize}
+	for name, target := range map[string]*int{"offset": &p.Offset, "limit": &p.Limit} {
+		text := r.URL.Query().Get(name)
+		if text == "" {
+			continue
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil || n < 0 {
+			return page{}, badRequest(fmt.Errorf("%s must be a non-negative integer", name))
+		}
+		*target = n
+	}
+	p.Limit = min(p.Limit, maxPageSize)
+	start := min(p.Offset, len(routes))
+	end := min(start+p.Limit, len(routes))
+	p.Routes = append([]Route{}, routes[start:end]...)
+	return p, nil
+}
+
+// respond writes v as JSON with an ETag computed from the body and answers
+// 304 when the client already has that version.
+func respond(w http.ResponseWriter, r *http.Request, v any, err error) {
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusInternalServerError
+		var he *httpError
+		switch {
+		case errors.As(err, &he):
+			status = he.status
+		case errors.Is(err, ErrUnknownStation), errors.Is(err, ErrNoPath):
+			status = http.StatusNotFound
+		}
+		v = map[string]string{"error": err.Error()}
+	}
+
+	var body bytes.Buffer
+	if err := writeJSON(&body, v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if status == http.StatusOK {
+		sum := sha256.Sum256(body.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		w.Write(body.Bytes())
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists etag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	respond(w, r, nil, &httpError{http.StatusMethodNotAllowed, errors.New("method not allowed")})
+	return false
+}
+
+// handleRoutes lists routes, optionally filtered by ?query= (the filter
+// command's language) and ordered by ?sort=field[:desc],...
+func (s *routeServer) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	q := &Query{}
+	if text := r.URL.Query().Get("query"); text != "" {
+		parsed, err := parseQuery(text)
+		if err != nil {
+			respond(w, r, nil, badRequest(fmt.Errorf("query: %w", err)))
+			return
+		}
+		q = parsed
+	}
+	if text := r.URL.Query().Get("sort"); text != "" {
+		keys, err := parseOrderKeys(text)
+		if err != nil {
+			respond(w, r, nil, badRequest(fmt.Errorf("sort: %w", err)))
+			return
+		}
+		q.OrderBy = keys
+	}
+	p, err := paginate(r, s.snapshot().Apply(q))
+	respond(w, r, p, err)
+}
+
+func (s *routeServer) handleMaxStops(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	p, err := paginate(r, findRoutesWithMaxStops(s.snapshot().Routes()))
+	respond(w, r, p, err)
+}
+
+func (s *routeServer) handleAvgBelow(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	x, err := strconv.ParseFloat(r.URL.Query().Get("x"), 64)
+	if err != nil {
+		respond(w, r, nil, badRequest(errors.New("x must be a number")))
+		return
+	}
+	q := &Query{Where: comparison{field: "avg", op: "<", number: x}}
+	p, err := paginate(r, s.snapshot().Apply(q))
+	respond(w, r, p, err)
+}
+
+func (s *routeServer) handlePath(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	params := r.URL.Query()
+	from, to := params.Get("from"), params.Get("to")
+	if from == "" || to == "" {
+		respond(w, r, nil, badRequest(errors.New("from and to are required")))
+		return
+	}
+	weightName := params.Get("weight")
+	if weightName == "" {
+		weightName = "distance"
+	}
+	weight, err := weightFuncByName(weightName)
+	if err != nil {
+		respond(w, r, nil, badRequest(err))
+		return
+	}
+	algorithm := params.Get("algo")
+	if algorithm == "" {
+		algorithm = "auto"
+	}
+	undirected, _ := strconv.ParseBool(params.Get("undirected"))
+
+	g := buildStationGraph(s.snapshot().Routes(), weight, undirected)
+	path, err := shortestPath(g, from, to, algorithm, nil)
+	if err != nil && !errors.Is(err, ErrUnknownStation) && !errors.Is(err, ErrNoPath) {
+		err = badRequest(err)
+	}
+	respond(w, r, path, err)
+}
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	file := fs.String("file", "routes.csv", "routes file: CSV, route store (.db) or GTFS feed")
+	delimName := fs.String("delim", "auto", "field delimiter: auto, comma, semicolon, tab or pipe")
+	lenient := fs.Bool("lenient", false, "skip invalid rows instead of failing")
+	addr := fs.String("addr", ":8080", "listen address")
+	poll := fs.Duration("poll", 2*time.Second, "how often to check the file for changes (0 disables reloading)")
+	fs.Parse(args)
+
+	delim, err := parseDelimiter(*delimName)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	s, err := newRouteServer(*file, ReadOptions{Delimiter: delim, Lenient: *lenient})
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *poll > 0 {
+		go s.watch(ctx, *poll)
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	log.Printf("serving %s on %s", *file, *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Println("Error serving:", err)
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const serverTestCSV = `Station A,Station B,3,10.5
+Station C,Station D,4,15.0
+Station A,Station E,5,25.0
+Station B,Station C,2,8.0
+Station A,Station C,6,20.0
+`
+
+func newTestServer(t *testing.T, file string) (*routeServer, *httptest.Server) {
+	t.Helper()
+	s, err := newRouteServer(file, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getPage(t *testing.T, url string, header http.Header) (*http.Response, page) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var p page
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp, p
+}
+
+func TestServerPagination(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "routes.csv")
+	writeTestFile(t, file, serverTestCSV)
+	_, ts := newTestServer(t, file)
+
+	tests := []struct {
+		query  string
+		status int
+		total  int
+		starts []string
+	}{
+		{"", http.StatusOK, 5, []string{"Station A", "Station C", "Station A", "Station B", "Station A"}},
+		{"?offset=1&limit=2", http.StatusOK, 5, []string{"Station C", "Station A"}},
+		{"?offset=4&limit=10", http.StatusOK, 5, []string{"Station A"}},
+		{"?offset=9", http.StatusOK, 5, nil},
+		{"?limit=0", http.StatusOK, 5, nil},
+		{"?query=start+%3D+%22Station+A%22&sort=distance:desc&limit=2", http.StatusOK, 3, []string{"Station A", "Station A"}},
+		{"?offset=-1", http.StatusBadRequest, 0, nil},
+		{"?limit=x", http.StatusBadRequest, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			resp, p := getPage(t, ts.URL+"/routes"+tt.query, nil)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var starts []string
+			for _, r := range p.Routes {
+				starts = append(starts, r.StartStation)
+			}
+			if p.Total != tt.total || strings.Join(starts, "|") != strings.Join(tt.starts, "|") {
+				t.Errorf("page = %+v, want total %d and starts %v", p, tt.total, tt.starts)
+			}
+		})
+	}
+
+	if _, p := getPage(t, ts.URL+"/routes?limit=5000", nil); p.Limit != maxPageSize {
+		t.Errorf("limit = %d, want it capped at %d", p.Limit, maxPageSize)
+	}
+}
+
+func TestServerETag(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "routes.csv")
+	writeTestFile(t, file, serverTestCSV)
+	s, ts := newTestServer(t, file)
+
+	resp, _ := getPage(t, ts.URL+"/routes", nil)
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag on the response")
+	}
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		resp, _ := getPage(t, ts.URL+"/routes", http.Header{"If-None-Match": {header}})
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("If-None-Match %s: status = %d, want 304", header, resp.StatusCode)
+		}
+	}
+	if resp, _ := getPage(t, ts.URL+"/routes", http.Header{"If-None-Match": {`"other"`}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("stale If-None-Match: status = %d, want 200", resp.StatusCode)
+	}
+	if resp, _ := getPage(t, ts.URL+"/routes?limit=1", nil); resp.Header.Get("ETag") == etag {
+		t.Error("a different page has the same ETag")
+	}
+
+	writeTestFile(t, file, serverTestCSV+"Station D,Station E,1,5.0\n")
+	if _, err := s.reload(); err != nil {
+		t.Fatal(err)
+	}
+	resp, _ = getPage(t, ts.URL+"/routes", http.Header{"If-None-Match": {etag}})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+		t.Errorf("after reload: status %d, ETag %s, want 200 and a new ETag", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+}
+
+func TestServerReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "routes.csv")
+	writeTestFile(t, file, serverTestCSV)
+	s, ts := newTestServer(t, file)
+
+	if changed, err := s.reload(); err != nil || changed {
+		t.Fatalf("reload of an unchanged file = %v, %v", changed, err)
+	}
+
+	writeTestFile(t, file, "Station X,Station Y,1,2.0\n")
+	if changed, err := s.reload(); err != nil || !changed {
+		t.Fatalf("reload of a changed file = %v, %v", changed, err)
+	}
+	if _, p := getPage(t, ts.URL+"/routes", nil); p.Total != 1 || p.Routes[0].StartStation != "Station X" {
+		t.Errorf("after reload: %+v", p)
+	}
+
+	// A broken file keeps the previous routes in service.
+	writeTestFile(t, file, "Station X,Station Y,many,2.0\n")
+	if _, err := s.reload(); err == nil {
+		t.Error("reload of an invalid file succeeded")
+	}
+	if _, p := getPage(t, ts.URL+"/routes", nil); p.Total != 1 {
+		t.Errorf("after a failed reload: %+v", p)
+	}
+}
+
+func TestServerReloadStore(t *testing.T) {
+	store, path := newTestStore(t, storeTestRoutes...)
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, ts := newTestServer(t, path)
+	if _, p := getPage(t, ts.URL+"/routes", nil); p.Total != len(storeTestRoutes) {
+		t.Fatalf("serving the store: %+v", p)
+	}
+
+	store, err := openRouteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Update(func(tx *StoreTx) error {
+		_, err := tx.Insert(Route{"D", "E", 1, 1})
+		return err
+	})
+	if err := errors.Join(err, store.Close()); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := s.reload(); err != nil || !changed {
+		t.Fatalf("reload after a store update = %v, %v", changed, err)
+	}
+	if _, p := getPage(t, ts.URL+"/routes", nil); p.Total != len(storeTestRoutes)+1 {
+		t.Errorf("after reload: %+v", p)
+	}
+}
+
+func TestServerReloadGTFSDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for name, file := range gtfsFeed(nil) {
+		writeTestFile(t, filepath.Join(dir, name), string(file.Data))
+	}
+	s, ts := newTestServer(t, dir)
+	if _, p := getPage(t, ts.URL+"/routes", nil); p.Total != 2 {
+		t.Fatalf("serving the feed: %+v", p)
+	}
+
+	// Editing a file inside the feed does not touch the directory itself.
+	later := time.Now().Add(time.Minute)
+	writeTestFile(t, filepath.Join(dir, "trips.txt"), "route_id,service_id,trip_id\nR1,WK,t1\n")
+	if err := os.Chtimes(filepath.Join(dir, "trips.txt"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := s.reload(); err != nil || !changed {
+		t.Fatalf("reload after editing trips.txt = %v, %v", changed, err)
+	}
+	if _, p := getPage(t, ts.URL+"/routes", nil); p.Total != 1 {
+		t.Errorf("after reload: %+v", p)
+	}
+}
+
+func TestServerEndpoints(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "routes.csv")
+	writeTestFile(t, file, serverTestCSV+"Station X,Station Y,6,3.0\n")
+	_, ts := newTestServer(t, file)
+
+	tests := []struct {
+		method, path string
+		status       int
+		check        func(t *testing.T, body map[string]any)
+	}{
+		{"GET", "/routes/maxstops", http.StatusOK, func(t *testing.T, body map[string]any) {
+			if body["total"] != 2.0 {
+				t.Errorf("total = %v, want the two routes with 6 stops", body["total"])
+			}
+		}},
+		{"GET", "/routes/avg-below?x=1", http.StatusOK, func(t *testing.T, body map[string]any) {
+			// Only Station X -> Station Y averages 0.5 km per stop.
+			if body["total"] != 1.0 {
+				t.Errorf("total = %v, want 1", body["total"])
+			}
+		}},
+		{"GET", "/routes/avg-below?x=100&limit=2", http.StatusOK, func(t *testing.T, body map[string]any) {
+			if body["total"] != 6.0 || len(body["routes"].([]any)) != 2 {
+				t.Errorf("body = %v, want 6 routes in total and 2 on the page", body)
+			}
+		}},
+		{"GET", "/routes/avg-below", http.StatusBadRequest, nil},
+		{"GET", "/routes/avg-below?x=far", http.StatusBadRequest, nil},
+		{"GET", "/path?from=Station+A&to=Station+D", http.StatusOK, func(t *testing.T, body map[string]any) {
+			if body["distance"] != 33.5 {
+				t.Errorf("distance = %v, want 33.5 via Station B and Station C", body["distance"])
+			}
+		}},
+		{"GET", "/path?from=Station+A&to=Station+D&weight=hops", http.StatusOK, func(t *testing.T, body map[string]any) {
+			if body["cost"] != 2.0 {
+				t.Errorf("cost = %v, want 2 hops", body["cost"])
+			}
+		}},
+		{"GET", "/path?from=Station+D&to=Station+A&undirected=true&algo=bellman-ford", http.StatusOK, nil},
+		{"GET", "/path?from=Station+A", http.StatusBadRequest, nil},
+		{"GET", "/path?from=Station+A&to=Nowhere", http.StatusNotFound, nil},
+		{"GET", "/path?from=Station+D&to=Station+A", http.StatusNotFound, nil},
+		{"GET", "/path?from=Station+A&to=Station+D&weight=price", http.StatusBadRequest, nil},
+		{"GET", "/path?from=Station+A&to=Station+D&algo=guess", http.StatusBadRequest, nil},
+		{"HEAD", "/routes", http.StatusOK, nil},
+		{"POST", "/routes", http.StatusMethodNotAllowed, nil},
+		{"DELETE", "/routes/maxstops", http.StatusMethodNotAllowed, nil},
+		{"PUT", "/routes/avg-below?x=1", http.StatusMethodNotAllowed, nil},
+		{"POST", "/path?from=Station+A&to=Station+D", http.StatusMethodNotAllowed, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == http.StatusMethodNotAllowed && resp.Header.Get("Allow") != "GET, HEAD" {
+				t.Errorf("Allow = %q, want \"GET, HEAD\"", resp.Header.Get("Allow"))
+			}
+			if tt.method == http.MethodHead {
+				return
+			}
+			var body map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if tt.status != http.StatusOK {
+				if _, ok := body["error"].(string); !ok {
+					t.Errorf("body = %v, want an error message", body)
+				}
+				return
+			}
+			if tt.check != nil {
+				tt.check(t, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Summary describes the distribution of a set of values.
+type Summary struct {
+	Count       int                `json:"count"`
+	Total       float64            `json:"total"`
+	Mean        float64            `json:"mean"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Median      float64            `json:"median"`
+	Percentiles map[string]float64 `json:"percentiles,omitempty"`
+}
+
+type HistogramBin struct {
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Count int     `json:"count"`
+}
+
+type StationStats struct {
+	Station  string `json:"station"`
+	Outgoing int    `json:"outgoing"`
+	Incoming int    `json:"incoming"`
+}
+
+type GroupStats struct {
+	Key      string  `json:"key"`
+	Distance Summary `json:"distance"`
+	Spacing  Summary `json:"spacing"`
+}
+
+type StatsReport struct {
+	Routes           int            `json:"routes"`
+	TotalStops       int            `json:"total_stops"`
+	Distance         Summary        `json:"distance"`
+	Stops            Summary        `json:"stops"`
+	Spacing          Summary        `json:"spacing"`
+	SpacingHistogram []HistogramBin `json:"spacing_histogram"`
+	Stations         []StationStats `json:"stations"`
+	GroupBy          string         `json:"group_by,omitempty"`
+	Groups           []GroupStats   `json:"groups,omitempty"`
+}
+
+// percentile returns the p-th percentile (0..100) of sorted values using
+// linear interpolation between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+func summarize(values []float64, percentiles []float64) Summary {
+	s := Summary{Count: len(values)}
+	if len(values) == 0 {
+		return s
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	for _, v := range sorted {
+		s.Total += v
+	}
+	s.Mean = s.Total / float64(len(sorted))
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Median = percentile(sorted, 50)
+	if len(percentiles) > 0 {
+		s.Percentiles = make(map[string]float64)
+		for _, p := range percentiles {
+			s.Percentiles["p"+strconv.FormatFloat(p, 'g', -1, 64)] = percentile(sorted, p)
+		}
+	}
+	return s
+}
+
+// histogram splits [min, max] into bins of equal width; the last bin
+// includes max.
+func histogram(values []float64, bins int) []HistogramBin {
+	if len(values) == 0 || bins < 1 {
+		return nil
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	width := (hi - lo) / float64(bins)
+	if width == 0 {
+		return []HistogramBin{{Low: lo, High: hi, Count: len(values)}}
+	}
+	result := make([]HistogramBin, bins)
+	for i := range result {
+		result[i].Low = lo + float64(i)*width
+		result[i].High = lo + float64(i+1)*width
+	}
+	for _, v := range values {
+		i := min(int((v-lo)/width), bins-1)
+		result[i].Count++
+	}
+	return result
+}
+
+// routeSpacings returns the average stop spacing of every route with stops.
+func routeSpacings(routes []Route) []float64 {
+	var spacings []float64
+	for _, r := range routes {
+		if r.NumStops > 0 {
+			spacings = append(spacings, avgStopLength(r))
+		}
+	}
+	return spacings
+}
+
+func routeDistances(routes []Route) []float64 {
+	distances := make([]float64, len(routes))
+	for i, r := range routes {
+		distances[i] = r.Distance
+	}
+	return distances
+}
+
+func stationStats(routes []Route) []StationStats {
+	byName := make(map[string]*StationStats)
+	get := func(name string) *StationStats {
+		s, ok := byName[name]
+		if !ok {
+			s = &StationStats{Station: name}
+			byName[name] = s
+		}
+		return s
+	}
+	for _, r := range routes {
+		get(r.StartStation).Outgoing++
+		get(r.EndStation).Incoming++
+	}
+	result := make([]StationStats, 0, len(byName))
+	for _, s := range byName {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Station < result[j].Station })
+	return result
+}
+
+// groupRoutes aggregates routes by start or end station.
+func groupRoutes(routes []Route, by string, percentiles []float64) ([]GroupStats, error) {
+	key, ok := stringFields[by]
+	if !ok {
+		return nil, fmt.Errorf("cannot group by %q, expected start or end", by)
+	}
+	groups := make(map[string][]Route)
+	for _, r := range routes {
+		groups[key(r)] = append(groups[key(r)], r)
+	}
+	result := make([]GroupStats, 0, len(groups))
+	for k, rs := range groups {
+		result = append(result, GroupStats{
+			Key:      k,
+			Distance: summarize(routeDistances(rs), percentiles),
+			Spacing:  summarize(routeSpacings(rs), nil),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+func buildStatsReport(routes []Route, groupBy string, bins int, percentiles []float64) (StatsReport, error) {
+	stops := make([]float64, len(routes))
+	report := StatsReport{Routes: len(routes), GroupBy: groupBy}
+	for i, r := range routes {
+		stops[i] = float64(r.NumStops)
+		report.TotalStops += r.NumStops
+	}
+	spacings := routeSpacings(routes)
+	report.Distance = summarize(routeDistances(routes), percentiles)
+	report.Stops = summarize(stops, percentiles)
+	report.Spacing = summarize(spacings, percentiles)
+	report.SpacingHistogram = histogram(spacings, bins)
+	report.Stations = stationStats(routes)
+	if groupBy != "" {
+		groups, err := groupRoutes(routes, groupBy, percentiles)
+		if err != nil {
+			return StatsReport{}, err
+		}
+		report.Groups = groups
+	}
+	return report, nil
+}
+
+func formatSummary(s Summary, unit string) string {
+	if s.Count == 0 {
+		return "n/a"
+	}
+	text := fmt.Sprintf("n=%d total=%.2f%s mean=%.2f median=%.2f min=%.2f max=%.2f",
+		s.Count, s.Total, unit, s.Mean, s.Median, s.Min, s.Max)
+	keys := make([]string, 0, len(s.Percentiles))
+	for k := range s.Percentiles {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.ParseFloat(keys[i][1:], 64)
+		b, _ := strconv.ParseFloat(keys[j][1:], 64)
+		return a < b
+	})
+	for _, k := range keys {
+		text += fmt.Sprintf(" %s=%.2f", k, s.Percentiles[k])
+	}
+	return text
+}
+
+func writeStatsReport(w io.Writer, report StatsReport) error {
+	fmt.Fprintf(w, "Routes: %d, stations: %d, total stops: %d\n", report.Routes, len(report.Stations), report.TotalStops)
+	fmt.Fprintf(w, "Distance (km): %s\n", formatSummary(report.Distance, " km"))
+	fmt.Fprintf(w, "Stops: %s\n", formatSummary(report.Stops, ""))
+	fmt.Fprintf(w, "Stop spacing (km): %s\n", formatSummary(report.Spacing, " km"))
+
+	if len(report.SpacingHistogram) > 0 {
+		fmt.Fprintln(w, "\nStop spacing histogram:")
+		maxCount := 0
+		for _, b := range report.SpacingHistogram {
+			maxCount = max(maxCount, b.Count)
+		}
+		for _, b := range report.SpacingHistogram {
+			bar := strings.Repeat("#", b.Count*40/max(maxCount, 1))
+			fmt.Fprintf(w, "  %8.2f - %8.2f  %5d  %s\n", b.Low, b.High, b.Count, bar)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nSTATION\tOUTGOING\tINCOMING")
+	for _, s := range report.Stations {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", s.Station, s.Outgoing, s.Incoming)
+	}
+	if len(report.Groups) > 0 {
+		fmt.Fprintf(tw, "\nGROUP (%s)\tROUTES\tTOTAL KM\tMEAN KM\tMEDIAN KM\tMEAN SPACING\n", report.GroupBy)
+		for _, g := range report.Groups {
+			fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\n",
+				g.Key, g.Distance.Count, g.Distance.Total, g.Distance.Mean, g.Distance.Median, g.Spacing.Mean)
+		}
+	}
+	return tw.Flush()
+}
+
+func parsePercentiles(s string) ([]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var result []float64
+	for _, field := range strings.Split(s, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid percentile %q", field)
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func runStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	opts := addCommonFlags(fs)
+	groupBy := fs.String("group-by", "", "aggregate per start or end station")
+	bins := fs.Int("bins", 10, "number of stop spacing histogram bins")
+	percentilesFlag := fs.String("percentiles", "25,75,90,95", "comma-separated percentiles to report")
+	fs.Parse(args)
+
+	percentiles, err := parsePercentiles(*percentilesFlag)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	routes, err := opts.load()
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	report, err := buildStatsReport(routes, *groupBy, *bins, percentiles)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	switch *opts.format {
+	case "json", "ndjson":
+		err = writeJSON(os.Stdout, report)
+	default:
+		err = writeStatsReport(os.Stdout, report)
+	}
+	if err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}
@@ -0,0 +1,678 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+var (
+	ErrRouteNotFound = errors.New("route not found")
+	ErrCorruptStore  = errors.New("corrupt store record")
+	ErrStoreClosed   = errors.New("store is closed")
+	ErrStoreLocked   = errors.New("store is in use by another process")
+)
+
+// syncFile flushes a file to disk; tests replace it to simulate failures.
+var syncFile = (*os.File).Sync
+
+// StoredRoute is a route together with the id the store assigned to it.
+type StoredRoute struct {
+	ID int64 `json:"id"`
+	Route
+}
+
+// storeRecord is one line of the log. Every line is prefixed with the
+// CRC-32 of its JSON so torn or damaged writes are detected. Records of a
+// transaction only take effect once its commit record is in the log.
+type storeRecord struct {
+	Tx    int64  `json:"tx"`
+	Op    string `json:"op"` // put, delete or commit
+	ID    int64  `json:"id,omitempty"`
+	Route *Route `json:"route,omitempty"`
+}
+
+// storeIndex is the index file: where the latest version of every route
+// lives in the log, valid for the first LogSize bytes of the log as long as
+// their CRC-32 is still LogCRC.
+type storeIndex struct {
+	LogSize int64           `json:"log_size"`
+	LogCRC  uint32          `json:"log_crc"`
+	NextID  int64           `json:"next_id"`
+	NextTx  int64           `json:"next_tx"`
+	Offsets map[int64]int64 `json:"offsets"`
+}
+
+// RouteStore is an embedded database of routes kept in an append-only log
+// file and an index file next to it (path + ".idx"). A lock file
+// (path + ".lock") keeps other processes from opening the same store.
+type RouteStore struct {
+	path string
+	lock *os.File
+
+	mu    sync.Mutex
+	log   *os.File
+	index storeIndex
+}
+
+func encodeRecord(rec storeRecord) ([]byte, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	line := fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(data), data)
+	return []byte(line), nil
+}
+
+func decodeRecord(line []byte) (storeRecord, error) {
+	var rec storeRecord
+	sum, data, ok := bytes.Cut(bytes.TrimSuffix(line, []byte("\n")), []byte(" "))
+	if !ok {
+		return rec, ErrCorruptStore
+	}
+	want, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || uint32(want) != crc32.ChecksumIEEE(data) {
+		return rec, ErrCorruptStore
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return rec, ErrCorruptStore
+	}
+	return rec, nil
+}
+
+// openRouteStore opens or creates the store at path. The index file is
+// used when it matches the log; records after it are replayed. An
+// incomplete transaction at the end of the log, left by a crash, is cut off;
+// a damaged record anywhere else is reported as ErrCorruptStore.
+func openRouteStore(path string) (*RouteStore, error) {
+	lock, err := lockFile(path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	log, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	s := &RouteStore{path: path, lock: lock, log: log}
+	if err := s.recover(); err != nil {
+		log.Close()
+		lock.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// logCRC returns the CRC-32 of the first size bytes of the log.
+func (s *RouteStore) logCRC(size int64) (uint32, error) {
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, io.NewSectionReader(s.log, 0, size)); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
+
+func (s *RouteStore) recover() error {
+	info, err := s.log.Stat()
+	if err != nil {
+		return err
+	}
+	s.index = storeIndex{NextID: 1, NextTx: 1, Offsets: make(map[int64]int64)}
+	if data, err := os.ReadFile(s.path + ".idx"); err == nil {
+		var idx storeIndex
+		if json.Unmarshal(data, &idx) == nil && idx.LogSize <= info.Size() && idx.Offsets != nil {
+			sum, err := s.logCRC(idx.LogSize)
+			if err != nil {
+				return err
+			}
+			if sum == idx.LogCRC {
+				s.index = idx
+			}
+		}
+	}
+
+	// Only the last line of the log may be damaged: that is a write torn by
+	// a crash. A bad record followed by more data means the log itself is
+	// corrupt, and cutting it off there would silently lose transactions.
+	reader := bufio.NewReader(io.NewSectionReader(s.log, s.index.LogSize, info.Size()-s.index.LogSize))
+	offset := s.index.LogSize
+	committed := offset
+	crc := s.index.LogCRC
+	var pending []storeRecord
+	var pendingOffsets []int64
+	var pendingData []byte
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		rec, err := decodeRecord(line)
+		if err != nil {
+			if _, peekErr := reader.Peek(1); peekErr != io.EOF {
+				return fmt.Errorf("%w at offset %d", ErrCorruptStore, offset)
+			}
+			break
+		}
+		lineOffset := offset
+		offset += int64(len(line))
+		pendingData = append(pendingData, line...)
+		if rec.Op != "commit" {
+			pending = append(pending, rec)
+			pendingOffsets = append(pendingOffsets, lineOffset)
+			continue
+		}
+		for i, p := range pending {
+			s.apply(p, pendingOffsets[i])
+		}
+		pending, pendingOffsets = nil, nil
+		crc = crc32.Update(crc, crc32.IEEETable, pendingData)
+		pendingData = pendingData[:0]
+		s.index.NextTx = max(s.index.NextTx, rec.Tx+1)
+		committed = offset
+	}
+
+	if committed < info.Size() {
+		if err := s.log.Truncate(committed); err != nil {
+			return err
+		}
+	}
+	s.index.LogSize, s.index.LogCRC = committed, crc
+	_, err = s.log.Seek(committed, io.SeekStart)
+	return err
+}
+
+func (s *RouteStore) apply(rec storeRecord, offset int64) {
+	switch rec.Op {
+	case "put":
+		s.index.Offsets[rec.ID] = offset
+		s.index.NextID = max(s.index.NextID, rec.ID+1)
+	case "delete":
+		delete(s.index.Offsets, rec.ID)
+	}
+}
+
+func (s *RouteStore) readAt(offset int64) (Route, error) {
+	line, err := bufio.NewReader(io.NewSectionReader(s.log, offset, s.index.LogSize-offset)).ReadBytes('\n')
+	if err != nil {
+		return Route{}, err
+	}
+	rec, err := decodeRecord(line)
+	if err != nil {
+		return Route{}, err
+	}
+	if rec.Route == nil {
+		return Route{}, ErrCorruptStore
+	}
+	return *rec.Route, nil
+}
+
+// Get returns the route stored under id.
+func (s *RouteStore) Get(id int64) (Route, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return Route{}, ErrStoreClosed
+	}
+	offset, ok := s.index.Offsets[id]
+	if !ok {
+		return Route{}, fmt.Errorf("%w: %d", ErrRouteNotFound, id)
+	}
+	return s.readAt(offset)
+}
+
+// All returns every stored route ordered by id.
+func (s *RouteStore) All() ([]StoredRoute, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.all()
+}
+
+func (s *RouteStore) all() ([]StoredRoute, error) {
+	if s.log == nil {
+		return nil, ErrStoreClosed
+	}
+	result := make([]StoredRoute, 0, len(s.index.Offsets))
+	for id, offset := range s.index.Offsets {
+		r, err := s.readAt(offset)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %w", id, err)
+		}
+		result = append(result, StoredRoute{ID: id, Route: r})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// Routes returns the stored routes without their ids.
+func (s *RouteStore) Routes() ([]Route, error) {
+	stored, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	routes := make([]Route, len(stored))
+	for i, sr := range stored {
+		routes[i] = sr.Route
+	}
+	return routes, nil
+}
+
+// StoreTx collects the writes of one transaction; see RouteStore.Update.
+type StoreTx struct {
+	store   *RouteStore
+	records []storeRecord
+	nextID  int64
+	exists  map[int64]bool
+}
+
+func (tx *StoreTx) has(id int64) bool {
+	if exists, ok := tx.exists[id]; ok {
+		return exists
+	}
+	_, ok := tx.store.index.Offsets[id]
+	return ok
+}
+
+// Insert adds a new route and returns its id.
+func (tx *StoreTx) Insert(r Route) (int64, error) {
+	if err := validateRoute(r); err != nil {
+		return 0, err
+	}
+	id := tx.nextID
+	tx.nextID++
+	tx.records = append(tx.records, storeRecord{Op: "put", ID: id, Route: &r})
+	tx.exists[id] = true
+	return id, nil
+}
+
+// Put replaces the route stored under an existing id.
+func (tx *StoreTx) Put(id int64, r Route) error {
+	if !tx.has(id) {
+		return fmt.Errorf("%w: %d", ErrRouteNotFound, id)
+	}
+	if err := validateRoute(r); err != nil {
+		return err
+	}
+	tx.records = append(tx.records, storeRecord{Op: "put", ID: id, Route: &r})
+	return nil
+}
+
+func (tx *StoreTx) Delete(id int64) error {
+	if !tx.has(id) {
+		return fmt.Errorf("%w: %d", ErrRouteNotFound, id)
+	}
+	tx.records = append(tx.records, storeRecord{Op: "delete", ID: id})
+	tx.exists[id] = false
+	return nil
+}
+
+// Update runs fn in a transaction. The writes made through tx are appended
+// to the log together with a commit record and synced to disk only if fn
+// returns nil; otherwise none of them take effect.
+func (s *RouteStore) Update(fn func(tx *StoreTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return ErrStoreClosed
+	}
+
+	tx := &StoreTx{store: s, nextID: s.index.NextID, exists: make(map[int64]bool)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.records) == 0 {
+		return nil
+	}
+
+	txID := s.index.NextTx
+	var buf bytes.Buffer
+	offsets := make([]int64, len(tx.records))
+	for i, rec := range append(tx.records, storeRecord{Op: "commit"}) {
+		rec.Tx = txID
+		line, err := encodeRecord(rec)
+		if err != nil {
+			return err
+		}
+		if i < len(offsets) {
+			offsets[i] = s.index.LogSize + int64(buf.Len())
+		}
+		buf.Write(line)
+	}
+	_, err := s.log.WriteAt(buf.Bytes(), s.index.LogSize)
+	if err == nil {
+		err = syncFile(s.log)
+	}
+	if err != nil {
+		// Cut the transaction off again so that reopening the store does
+		// not replay an update the caller was told had failed.
+		return errors.Join(err, s.log.Truncate(s.index.LogSize))
+	}
+
+	for i, rec := range tx.records {
+		s.apply(rec, offsets[i])
+	}
+	s.index.NextID = max(s.index.NextID, tx.nextID)
+	s.index.NextTx = txID + 1
+	s.index.LogSize += int64(buf.Len())
+	s.index.LogCRC = crc32.Update(s.index.LogCRC, crc32.IEEETable, buf.Bytes())
+	return nil
+}
+
+// writeIndex saves the index atomically by renaming a temporary file.
+func (s *RouteStore) writeIndex() error {
+	data, err := json.Marshal(s.index)
+	if err != nil {
+		return err
+	}
+	return replaceFile(s.path+".idx", data)
+}
+
+// replaceFile atomically replaces path with data: the data is synced to a
+// temporary file, renamed over path, and the rename is made durable by
+// syncing the directory.
+func replaceFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if err := errors.Join(err, file.Close()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	return errors.Join(dir.Sync(), dir.Close())
+}
+
+// Compact rewrites the log with only the current version of every route,
+// as a single transaction, and replaces the old log atomically.
+func (s *RouteStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.all()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	index := storeIndex{NextID: s.index.NextID, NextTx: 2, Offsets: make(map[int64]int64)}
+	for _, sr := range stored {
+		r := sr.Route
+		line, err := encodeRecord(storeRecord{Tx: 1, Op: "put", ID: sr.ID, Route: &r})
+		if err != nil {
+			return err
+		}
+		index.Offsets[sr.ID] = int64(buf.Len())
+		buf.Write(line)
+	}
+	if len(stored) > 0 {
+		line, err := encodeRecord(storeRecord{Tx: 1, Op: "commit"})
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+	}
+	index.LogSize = int64(buf.Len())
+	index.LogCRC = crc32.ChecksumIEEE(buf.Bytes())
+
+	if err := replaceFile(s.path, buf.Bytes()); err != nil {
+		return err
+	}
+	log, err := os.OpenFile(s.path, os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	s.log.Close()
+	s.log, s.index = log, index
+	return s.writeIndex()
+}
+
+// Close writes the index file and closes the log.
+func (s *RouteStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return ErrStoreClosed
+	}
+	indexErr := s.writeIndex()
+	err := s.log.Close()
+	s.log = nil
+	return errors.Join(indexErr, err, s.lock.Close())
+}
+
+// validateRoute applies the same rules as the CSV reader.
+func validateRoute(r Route) error {
+	record := []string{r.StartStation, r.EndStation, strconv.Itoa(r.NumStops), formatDistance(r.Distance)}
+	if _, rowErr := parseRoute(record, [numColumns]int{colStart, colEnd, colStops, colDistance}, 0); rowErr != nil {
+		return fmt.Errorf("%s: %w", rowErr.Column, rowErr.Err)
+	}
+	return nil
+}
+
+func isStorePath(path string) bool {
+	return strings.HasSuffix(path, ".db")
+}
+
+func readRoutesFromStore(path string) ([]Route, error) {
+	s, err := openRouteStore(path)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	return s.Routes()
+}
+
+// routeFromArgs parses "start end stops distance" given on the command line.
+func routeFromArgs(args []string) (Route, error) {
+	if len(args) != 4 {
+		return Route{}, errors.New("expected <start> <end> <stops> <distance>")
+	}
+	r, rowErr := parseRoute(args, [numColumns]int{colStart, colEnd, colStops, colDistance}, 0)
+	if rowErr != nil {
+		return Route{}, fmt.Errorf("%s %q: %w", rowErr.Column, rowErr.Value, rowErr.Err)
+	}
+	return r, nil
+}
+
+func parseRouteID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid route id %q", s)
+	}
+	return id, nil
+}
+
+const storeUsage = `Usage: go run *.go store [-db routes.db] [-format f] <action> [args]
+
+Actions:
+  import <routes file>                     add every route of a CSV file or GTFS feed in one transaction
+  list                                     list stored routes with their ids
+  get <id>                                 print one route
+  add <start> <end> <stops> <distance>     add a route
+  update <id> <start> <end> <stops> <distance>
+  delete <id>...                           delete routes in one transaction
+  export [file.csv]                        write the routes as CSV (stdout by default)
+  compact                                  rewrite the log without old versions`
+
+func runStore(args []string) {
+	fs := flag.NewFlagSet("store", flag.ExitOnError)
+	dbPath := fs.String("db", "routes.db", "store file; the index is kept in <db>.idx")
+	format := fs.String("format", "table", "output format for list: "+strings.Join(outputFormats, ", "))
+	fs.Usage = func() { fmt.Println(storeUsage) }
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Println(storeUsage)
+		return
+	}
+	s, err := openRouteStore(*dbPath)
+	if err != nil {
+		fmt.Println("Error opening store:", err)
+		return
+	}
+	defer func() {
+		if err := s.Close(); err != nil {
+			fmt.Println("Error closing store:", err)
+		}
+	}()
+
+	if err := storeAction(s, fs.Arg(0), fs.Args()[1:], *format); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+func storeAction(s *RouteStore, action string, args []string, format string) error {
+	switch action {
+	case "import":
+		if len(args) != 1 {
+			return errors.New("import expects a routes file")
+		}
+		routes, err := loadRoutes(args[0], ReadOptions{})
+		if err != nil {
+			return err
+		}
+		err = s.Update(func(tx *StoreTx) error {
+			for _, r := range routes {
+				if _, err := tx.Insert(r); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			fmt.Printf("imported %d routes\n", len(routes))
+		}
+		return err
+
+	case "list":
+		stored, err := s.All()
+		if err != nil {
+			return err
+		}
+		switch format {
+		case "table":
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tSTART\tEND\tSTOPS\tDISTANCE")
+			for _, sr := range stored {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%.2f\n", sr.ID, sr.StartStation, sr.EndStation, sr.NumStops, sr.Distance)
+			}
+			return tw.Flush()
+		case "csv":
+			routes, err := s.Routes()
+			if err != nil {
+				return err
+			}
+			return writeRoutes(os.Stdout, routes, format)
+		}
+		if err := checkFormat(format); err != nil {
+			return err
+		}
+		return writeJSON(os.Stdout, stored)
+
+	case "get":
+		if len(args) != 1 {
+			return errors.New("get expects an id")
+		}
+		id, err := parseRouteID(args[0])
+		if err != nil {
+			return err
+		}
+		r, err := s.Get(id)
+		if err != nil {
+			return err
+		}
+		return writeJSON(os.Stdout, StoredRoute{ID: id, Route: r})
+
+	case "add":
+		r, err := routeFromArgs(args)
+		if err != nil {
+			return err
+		}
+		var id int64
+		err = s.Update(func(tx *StoreTx) error {
+			id, err = tx.Insert(r)
+			return err
+		})
+		if err == nil {
+			fmt.Println("added route", id)
+		}
+		return err
+
+	case "update":
+		if len(args) != 5 {
+			return errors.New("update expects <id> <start> <end> <stops> <distance>")
+		}
+		id, err := parseRouteID(args[0])
+		if err != nil {
+			return err
+		}
+		r, err := routeFromArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return s.Update(func(tx *StoreTx) error { return tx.Put(id, r) })
+
+	case "delete":
+		if len(args) == 0 {
+			return errors.New("delete expects at least one id")
+		}
+		return s.Update(func(tx *StoreTx) error {
+			for _, arg := range args {
+				id, err := parseRouteID(arg)
+				if err != nil {
+					return err
+				}
+				if err := tx.Delete(id); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	case "export":
+		routes, err := s.Routes()
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			return writeRoutes(os.Stdout, routes, "csv")
+		}
+		file, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		if err := writeRoutes(file, routes, "csv"); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+
+	case "compact":
+		return s.Compact()
+	}
+	return fmt.Errorf("unknown action %q\n\n%s", action, storeUsage)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func newTestStore(t *testing.T, routes ...Route) (*RouteStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "routes.db")
+	s, err := openRouteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range routes {
+		err := s.Update(func(tx *StoreTx) error {
+			_, err := tx.Insert(r)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, path
+}
+
+func reopenRoutes(t *testing.T, path string) []Route {
+	t.Helper()
+	s, err := openRouteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	routes, err := s.Routes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return routes
+}
+
+var storeTestRoutes = []Route{{"A", "B", 1, 1}, {"B", "C", 2, 2}, {"C", "D", 3, 3}}
+
+func TestRouteStoreLock(t *testing.T) {
+	s, path := newTestStore(t)
+	if _, err := openRouteStore(path); !errors.Is(err, ErrStoreLocked) {
+		t.Errorf("second open: err = %v, want ErrStoreLocked", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := openRouteStore(path)
+	if err != nil {
+		t.Fatalf("open after close: %v", err)
+	}
+	again.Close()
+}
+
+func TestRouteStoreTornTail(t *testing.T) {
+	s, path := newTestStore(t, storeTestRoutes...)
+	s.Close()
+	os.Remove(path + ".idx")
+
+	log, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.WriteString(`1234abcd {"tx":9,"op":"put","id":9,"rou`)
+	log.Close()
+
+	if got := reopenRoutes(t, path); !reflect.DeepEqual(got, storeTestRoutes) {
+		t.Errorf("routes = %v, want %v", got, storeTestRoutes)
+	}
+}
+
+func TestRouteStoreCorruptRecord(t *testing.T) {
+	s, path := newTestStore(t, storeTestRoutes...)
+	s.Close()
+	os.Remove(path + ".idx")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openRouteStore(path); !errors.Is(err, ErrCorruptStore) {
+		t.Errorf("err = %v, want ErrCorruptStore", err)
+	}
+}
+
+func TestRouteStoreStaleIndex(t *testing.T) {
+	s, path := newTestStore(t, storeTestRoutes...)
+	s.Close()
+	index, err := os.ReadFile(path + ".idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A longer log with other records must not be read through the old
+	// index, whose size check alone would accept it.
+	other := []Route{{"North", "South", 1, 1}, {"South", "East", 2, 2}, {"East", "West", 3, 3}, {"West", "North", 4, 4}}
+	s, otherPath := newTestStore(t, other...)
+	s.Close()
+	data, err := os.ReadFile(otherPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".idx", index, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := reopenRoutes(t, path); !reflect.DeepEqual(got, other) {
+		t.Errorf("routes = %v, want %v", got, other)
+	}
+}
+
+func TestRouteStoreCompactConcurrent(t *testing.T) {
+	s, path := newTestStore(t, storeTestRoutes...)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := s.Compact(); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			err := s.Update(func(tx *StoreTx) error {
+				_, err := tx.Insert(Route{"E", "F", 1, 1})
+				return err
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reopenRoutes(t, path); len(got) != len(storeTestRoutes)+4 {
+		t.Errorf("%d routes after compaction, want %d", len(got), len(storeTestRoutes)+4)
+	}
+}
+
+func TestRouteStoreUpdateRollback(t *testing.T) {
+	s, path := newTestStore(t, storeTestRoutes...)
+	before := s.index.LogSize
+	fail := errors.New("changed my mind")
+	err := s.Update(func(tx *StoreTx) error {
+		if _, err := tx.Insert(Route{"X", "Y", 1, 1}); err != nil {
+			return err
+		}
+		if err := tx.Delete(1); err != nil {
+			return err
+		}
+		return fail
+	})
+	if !errors.Is(err, fail) {
+		t.Fatalf("err = %v, want the error returned by fn", err)
+	}
+	if s.index.LogSize != before {
+		t.Errorf("log grew from %d to %d bytes", before, s.index.LogSize)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reopenRoutes(t, path); !reflect.DeepEqual(got, storeTestRoutes) {
+		t.Errorf("routes = %v, want %v", got, storeTestRoutes)
+	}
+}
+
+func TestRouteStoreFailedCommit(t *testing.T) {
+	s, path := newTestStore(t, storeTestRoutes...)
+	before := s.index.LogSize
+
+	syncErr := errors.New("disk on fire")
+	syncFile = func(*os.File) error { return syncErr }
+	err := s.Update(func(tx *StoreTx) error {
+		_, err := tx.Insert(Route{"X", "Y", 1, 1})
+		return err
+	})
+	syncFile = (*os.File).Sync
+	if !errors.Is(err, syncErr) {
+		t.Fatalf("err = %v, want the sync error", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != before {
+		t.Fatalf("log is %v bytes after a failed commit, want %d (%v)", info.Size(), before, err)
+	}
+
+	// The store stays usable and the failed insert never shows up.
+	err = s.Update(func(tx *StoreTx) error {
+		_, err := tx.Insert(Route{"D", "E", 1, 1})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path + ".idx")
+	want := append(slices.Clone(storeTestRoutes), Route{"D", "E", 1, 1})
+	if got := reopenRoutes(t, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %v, want %v", got, want)
+	}
+}
+
+func TestRouteStoreFailedWrite(t *testing.T) {
+	s, path := newTestStore(t, storeTestRoutes...)
+	s.log.Close() // every write now fails
+	err := s.Update(func(tx *StoreTx) error {
+		_, err := tx.Insert(Route{"X", "Y", 1, 1})
+		return err
+	})
+	if err == nil {
+		t.Fatal("update on a closed log file succeeded")
+	}
+	s.log = nil
+	s.lock.Close()
+	if got := reopenRoutes(t, path); !reflect.DeepEqual(got, storeTestRoutes) {
+		t.Errorf("routes = %v, want %v", got, storeTestRoutes)
+	}
+}
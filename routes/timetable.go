@@ -0,0 +1,420 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	ErrInvalidTime  = errors.New("invalid time, expected HH:MM[:SS]")
+	ErrTimeTravel   = errors.New("arrival is before the previous departure")
+	ErrNotReachable = errors.New("station not reachable after departure time")
+)
+
+// StopTime is one row of a GTFS-like stop_times file: a trip calls at a
+// station, arriving and departing at seconds after midnight. Times past
+// 24:00:00 are allowed for trips running after midnight.
+type StopTime struct {
+	TripID    string
+	Station   string
+	Arrival   int
+	Departure int
+	Sequence  int
+}
+
+// Connection is a trip travelling between two consecutive stops without
+// calling anywhere in between.
+type Connection struct {
+	TripID    string
+	From      string
+	To        string
+	Departure int
+	Arrival   int
+}
+
+// Timetable holds the stop times of every trip and the connections derived
+// from them, ordered by departure for the connection scan.
+type Timetable struct {
+	Trips       map[string][]StopTime
+	Connections []Connection
+}
+
+var stopTimeColumns = []string{"trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"}
+
+// parseClock parses HH:MM or HH:MM:SS into seconds after midnight.
+func parseClock(s string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, ErrInvalidTime
+	}
+	var values [3]int
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 || (i > 0 && v > 59) {
+			return 0, ErrInvalidTime
+		}
+		values[i] = v
+	}
+	return values[0]*3600 + values[1]*60 + values[2], nil
+}
+
+func formatClock(seconds int) string {
+	s := fmt.Sprintf("%02d:%02d", seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		s += fmt.Sprintf(":%02d", seconds%60)
+	}
+	return s
+}
+
+// readStopTimes parses a stop_times CSV with a header naming at least
+// trip_id, arrival_time, departure_time, stop_id and stop_sequence. The
+// stop_id is used as the station name. An empty arrival or departure time
+// is filled from the other one.
+func readStopTimes(r io.Reader) ([]StopTime, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	positions := make(map[string]int)
+	for i, name := range header {
+		positions[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range stopTimeColumns {
+		if _, ok := positions[name]; !ok {
+			return nil, fmt.Errorf("header: %w: %s", ErrMissingField, name)
+		}
+	}
+
+	var stopTimes []StopTime
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i := positions[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		st := StopTime{TripID: field("trip_id"), Station: field("stop_id")}
+		if st.TripID == "" {
+			return nil, &RowError{Line: line, Column: "trip_id", Err: ErrMissingField}
+		}
+		if st.Station == "" {
+			return nil, &RowError{Line: line, Column: "stop_id", Err: ErrEmptyStation}
+		}
+		if st.Sequence, err = strconv.Atoi(field("stop_sequence")); err != nil {
+			return nil, &RowError{Line: line, Column: "stop_sequence", Value: field("stop_sequence"), Err: ErrInvalidNumber}
+		}
+		arrival, departure := field("arrival_time"), field("departure_time")
+		if arrival == "" {
+			arrival = departure
+		}
+		if departure == "" {
+			departure = arrival
+		}
+		if st.Arrival, err = parseClock(arrival); err != nil {
+			return nil, &RowError{Line: line, Column: "arrival_time", Value: arrival, Err: err}
+		}
+		if st.Departure, err = parseClock(departure); err != nil {
+			return nil, &RowError{Line: line, Column: "departure_time", Value: departure, Err: err}
+		}
+		stopTimes = append(stopTimes, st)
+	}
+	return stopTimes, nil
+}
+
+// newTimetable groups stop times by trip in stop_sequence order and derives
+// the connections between consecutive stops.
+func newTimetable(stopTimes []StopTime) (*Timetable, error) {
+	t := &Timetable{Trips: make(map[string][]StopTime)}
+	for _, st := range stopTimes {
+		t.Trips[st.TripID] = append(t.Trips[st.TripID], st)
+	}
+	for id, trip := range t.Trips {
+		sort.Slice(trip, func(i, j int) bool { return trip[i].Sequence < trip[j].Sequence })
+		for i := range trip {
+			if trip[i].Departure < trip[i].Arrival {
+				return nil, fmt.Errorf("trip %s at %s: %w", id, trip[i].Station, ErrTimeTravel)
+			}
+			if i == 0 {
+				continue
+			}
+			prev := trip[i-1]
+			if trip[i].Arrival < prev.Departure {
+				return nil, fmt.Errorf("trip %s at %s: %w", id, trip[i].Station, ErrTimeTravel)
+			}
+			t.Connections = append(t.Connections, Connection{
+				TripID:    id,
+				From:      prev.Station,
+				To:        trip[i].Station,
+				Departure: prev.Departure,
+				Arrival:   trip[i].Arrival,
+			})
+		}
+	}
+	sort.SliceStable(t.Connections, func(i, j int) bool {
+		a, b := t.Connections[i], t.Connections[j]
+		if a.Departure != b.Departure {
+			return a.Departure < b.Departure
+		}
+		return a.Arrival < b.Arrival
+	})
+	return t, nil
+}
+
+func readTimetableFromFile(filename string) (*Timetable, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stopTimes, err := readStopTimes(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return newTimetable(stopTimes)
+}
+
+func (t *Timetable) hasStation(name string) bool {
+	for _, trip := range t.Trips {
+		for _, st := range trip {
+			if st.Station == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tripRoutes describes every trip as a Route from its first to its last
+// stop. NumStops counts the stops after the first one; Distance is taken
+// from a route in routes with the same start and end, if any.
+func (t *Timetable) tripRoutes(routes []Route) []Route {
+	distances := make(map[[2]string]float64)
+	for _, r := range routes {
+		distances[[2]string{r.StartStation, r.EndStation}] = r.Distance
+	}
+	ids := make([]string, 0, len(t.Trips))
+	for id := range t.Trips {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var result []Route
+	for _, id := range ids {
+		trip := t.Trips[id]
+		if len(trip) < 2 {
+			continue
+		}
+		r := Route{StartStation: trip[0].Station, EndStation: trip[len(trip)-1].Station, NumStops: len(trip) - 1}
+		r.Distance = distances[[2]string{r.StartStation, r.EndStation}]
+		result = append(result, r)
+	}
+	return result
+}
+
+// TimedLeg is a ride on one trip between boarding and alighting.
+type TimedLeg struct {
+	TripID    string `json:"trip"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Departure string `json:"departure"`
+	Arrival   string `json:"arrival"`
+	NumStops  int    `json:"stops"`
+}
+
+type Itinerary struct {
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	Departure string     `json:"departure"`
+	Arrival   string     `json:"arrival"`
+	Duration  string     `json:"duration"`
+	Legs      []TimedLeg `json:"legs"`
+}
+
+// earliestArrival runs the Connection Scan Algorithm: connections are
+// scanned once in departure order, and a connection is usable when its trip
+// has already been boarded or its departure station is reached in time.
+// Changing trips anywhere but the origin requires minTransfer seconds.
+func (t *Timetable) earliestArrival(from, to string, departAt, minTransfer int) (Itinerary, error) {
+	for _, name := range []string{from, to} {
+		if !t.hasStation(name) {
+			return Itinerary{}, fmt.Errorf("%w: %s", ErrUnknownStation, name)
+		}
+	}
+
+	if from == to {
+		return Itinerary{From: from, To: to, Departure: formatClock(departAt), Arrival: formatClock(departAt), Duration: "0s"}, nil
+	}
+
+	arrival := map[string]int{from: departAt}
+	arrivalAt := func(station string) int {
+		if a, ok := arrival[station]; ok {
+			return a
+		}
+		return math.MaxInt
+	}
+	// boarded holds the index of the connection where each trip was boarded;
+	// reachedBy holds the boarding and alighting connections per station.
+	boarded := make(map[string]int)
+	reachedBy := make(map[string][2]int)
+
+	start := sort.Search(len(t.Connections), func(i int) bool { return t.Connections[i].Departure >= departAt })
+	for i := start; i < len(t.Connections); i++ {
+		c := t.Connections[i]
+		if c.Departure >= arrivalAt(to) {
+			break
+		}
+		boardedAt, onTrip := boarded[c.TripID]
+		if !onTrip {
+			ready := arrivalAt(c.From)
+			if ready == math.MaxInt {
+				continue
+			}
+			if c.From != from {
+				ready += minTransfer
+			}
+			if ready > c.Departure {
+				continue
+			}
+			boardedAt = i
+			boarded[c.TripID] = i
+		}
+		if c.Arrival < arrivalAt(c.To) {
+			arrival[c.To] = c.Arrival
+			reachedBy[c.To] = [2]int{boardedAt, i}
+		}
+	}
+
+	if _, ok := reachedBy[to]; !ok {
+		return Itinerary{}, fmt.Errorf("%w: %s -> %s after %s", ErrNotReachable, from, to, formatClock(departAt))
+	}
+
+	var legs []TimedLeg
+	var departure int
+	for at := to; at != from; {
+		ends := reachedBy[at]
+		board, alight := t.Connections[ends[0]], t.Connections[ends[1]]
+		legs = append(legs, TimedLeg{
+			TripID:    board.TripID,
+			From:      board.From,
+			To:        alight.To,
+			Departure: formatClock(board.Departure),
+			Arrival:   formatClock(alight.Arrival),
+			NumStops:  t.stopsBetween(board, alight),
+		})
+		departure = board.Departure
+		at = board.From
+	}
+	for i, j := 0, len(legs)-1; i < j; i, j = i+1, j-1 {
+		legs[i], legs[j] = legs[j], legs[i]
+	}
+
+	return Itinerary{
+		From:      from,
+		To:        to,
+		Departure: formatClock(departure),
+		Arrival:   formatClock(arrival[to]),
+		Duration:  (time.Duration(arrival[to]-departure) * time.Second).String(),
+		Legs:      legs,
+	}, nil
+}
+
+// stopsBetween counts the stops ridden on a trip from board to alight.
+func (t *Timetable) stopsBetween(board, alight Connection) int {
+	trip := t.Trips[board.TripID]
+	var from, to int
+	for i, st := range trip {
+		if st.Station == board.From && st.Departure == board.Departure {
+			from = i
+		}
+		if st.Station == alight.To && st.Arrival == alight.Arrival {
+			to = i
+		}
+	}
+	return to - from
+}
+
+func writeItinerary(w io.Writer, it Itinerary, format string) error {
+	if format == "json" {
+		return writeJSON(w, it)
+	}
+	fmt.Fprintf(w, "%s %s -> %s %s (%s, %d legs)\n", it.Departure, it.From, it.To, it.Arrival, it.Duration, len(it.Legs))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TRIP\tFROM\tDEPART\tTO\tARRIVE\tSTOPS")
+	for _, leg := range it.Legs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\n", leg.TripID, leg.From, leg.Departure, leg.To, leg.Arrival, leg.NumStops)
+	}
+	return tw.Flush()
+}
+
+func runTimetable(args []string) {
+	fs := flag.NewFlagSet("timetable", flag.ExitOnError)
+	stopTimesFile := fs.String("stop-times", "stop_times.csv", "GTFS-like stop_times CSV file")
+	depart := fs.String("depart", "00:00", "earliest departure time (HH:MM[:SS])")
+	minTransfer := fs.Duration("min-transfer", 0, "minimum time to change trips, e.g. 2m")
+	format := fs.String("format", "table", "output format: table or json")
+	trips := fs.Bool("trips", false, "list every trip as a route instead of planning a journey")
+	routesFile := fs.String("routes", "", "routes file (CSV, .db or GTFS feed) used to fill in trip distances with -trips")
+	fs.Parse(args)
+
+	t, err := readTimetableFromFile(*stopTimesFile)
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+
+	if *trips {
+		var routes []Route
+		if *routesFile != "" {
+			if routes, err = loadRoutes(*routesFile, ReadOptions{}); err != nil {
+				fmt.Println("Error reading file:", err)
+				return
+			}
+		}
+		if err := writeRoutes(os.Stdout, t.tripRoutes(routes), *format); err != nil {
+			fmt.Println("Error writing output:", err)
+		}
+		return
+	}
+
+	if fs.NArg() < 2 {
+		fmt.Println("Usage: go run *.go timetable [flags] <from> <to>")
+		return
+	}
+	departAt, err := parseClock(*depart)
+	if err != nil {
+		fmt.Println("Error parsing -depart:", err)
+		return
+	}
+	it, err := t.earliestArrival(fs.Arg(0), fs.Arg(1), departAt, int(minTransfer.Seconds()))
+	if err != nil {
+		fmt.Println("Error planning journey:", err)
+		return
+	}
+	if err := writeItinerary(os.Stdout, it, *format); err != nil {
+		fmt.Println("Error writing output:", err)
+	}
+}